@@ -2,22 +2,32 @@ package main
 
 import (
 	"fmt"
-	findaccount "github.com/johnsaigle/findaccount"
+	findaccount "github.com/johnsaigle/findaccount/pkg/account"
 	"log"
 	"os"
 	"sort"
+	"strings"
 )
 
 func main() {
 	if len(os.Args) == 2 {
-		accounts, err := findaccount.ConvertToAccounts(os.Args[1])
+		families, err := findaccount.ConvertToAddressFamilies(os.Args[1])
 		if err != nil {
-			log.Println(err)
+			log.Fatalln(err)
 		}
-		results := make([]string, len(accounts))
+		results := make([]string, len(families))
 		i := 0
-		for k, v := range accounts {
-			results[i] = fmt.Sprintf("%-14s: %s\n", k, v)
+		for k, v := range families {
+			fields := make([]string, 0, 5)
+			for _, addr := range []string{v.Account, v.Hex, v.Valoper, v.Valcons, v.Valconspub} {
+				if addr != "" {
+					fields = append(fields, addr)
+				}
+			}
+			if v.Mismatch {
+				fields = append(fields, "(different key derivation, not the same key)")
+			}
+			results[i] = fmt.Sprintf("%-14s: %s\n", k, strings.Join(fields, " "))
 			i += 1
 		}
 		sort.Strings(results)
@@ -25,6 +35,6 @@ func main() {
 			fmt.Print(s)
 		}
 	} else {
-		log.Fatalf("Error %s takes one argument, a bech32 encoded cosmos address\n", os.Args[0])
+		log.Fatalf("Error %s takes one argument, a bech32 account, valoper, valcons or valconspub address, or a hex/0x address\n", os.Args[0])
 	}
 }
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+  "fmt"
+  "log"
+  "sort"
+  "sync"
+
+  "github.com/spf13/cobra"
+  "github.com/johnsaigle/findaccount/pkg/chaininfo"
+  "github.com/johnsaigle/findaccount/pkg/client"
+)
+
+var cached bool
+
+var endpointsCmd = &cobra.Command{
+  Use:   "endpoints [chain...]",
+  Short: "Rank the RPC endpoints of each chain by health",
+  Long: `Probe every RPC endpoint in the chain-registry, or of the chains given as arguments, and print them ranked best first.
+  Endpoints are scored by latency, how far they lag behind the highest endpoint of the chain, how old their latest block is
+  and how often they failed earlier probes. Lower scores are better, unhealthy endpoints are not scored. The ranking is kept between runs and used to pick endpoints.`,
+  Run: func(cmd *cobra.Command, args []string) {
+    chains := args
+    if len(chains) == 0 {
+      for chain := range chaininfo.Infos {
+        chains = append(chains, chain)
+      }
+    }
+    sort.Strings(chains)
+    ctx, cancel := searchContext()
+    defer cancel()
+
+    rankings := make(map[string][]client.EndpointHealth)
+    var mux sync.Mutex
+    wg := &sync.WaitGroup{}
+    for _, chain := range chains {
+      info, ok := chaininfo.Infos[chain]
+      if !ok {
+        log.Println(chain, "is not in the chain-registry")
+        continue
+      }
+      chain := chain
+      wg.Add(1)
+      go func() {
+        defer wg.Done()
+        var ranking []client.EndpointHealth
+        if cached {
+          ranking = client.EndpointRanking(chain)
+        } else {
+          ranking, _ = client.ProbeChain(ctx, chain, info.Apis.Rpc)
+        }
+        mux.Lock()
+        rankings[chain] = ranking
+        mux.Unlock()
+      }()
+    }
+    wg.Wait()
+    if !cached {
+      saveHealth()
+    }
+
+    fmt.Println("chain,rank,address,healthy,score,latency ms,height,lag,catching up,error rate,last probed,error")
+    for _, chain := range chains {
+      for i, h := range rankings[chain] {
+        // unhealthy endpoints rank last whatever their score, which is left empty
+        score := ""
+        if h.Healthy {
+          score = fmt.Sprintf("%.0f", h.Score)
+        }
+        fmt.Printf("%s,%d,%s,%v,%s,%d,%d,%d,%v,%.2f,%s,%q\n", chain, i+1, h.Address, h.Healthy, score, h.Latency.Milliseconds(),
+          h.Height, h.Lag, h.CatchingUp, h.ErrorRate(), h.LastProbed.Format("2006-01-02T15:04:05Z"), h.Error)
+      }
+    }
+  },
+}
+
+func init() {
+  endpointsCmd.Flags().BoolVarP(&cached, "cached", "c", false, "Print the ranking saved by earlier runs without probing")
+  rootCmd.AddCommand(endpointsCmd)
+}
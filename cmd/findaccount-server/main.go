@@ -1,31 +1,63 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
-	findaccount "github.com/johnsaigle/findaccount"
-	"github.com/cosmos/cosmos-sdk/types/bech32"
-	"io/fs"
+	findaccount "github.com/johnsaigle/findaccount/pkg/account"
+	"github.com/johnsaigle/findaccount/pkg/chaininfo"
+	"github.com/johnsaigle/findaccount/pkg/client"
+	"github.com/johnsaigle/findaccount/static"
 	"log"
 	"net/http"
 	"net/netip"
+	"os"
+	"time"
 )
 
 func main() {
 	var port int
 	var xForwarded string
 	var useXForwarded bool
+	var revalidate time.Duration
+	var timeout time.Duration
+	var registry string
 
 	flag.IntVar(&port, "p", 8080, "http port to listen on")
 	flag.StringVar(&xForwarded, "h", "X-Forwarded-For", "optional: trusted X-Forwarded-For Header")
 	flag.BoolVar(&useXForwarded, "x", false, "Use the X-Forwarded-For header for logs (behind a reverse proxy)")
+	flag.DurationVar(&revalidate, "r", client.DefaultRevalidateInterval, "how often pooled RPC clients are checked for health")
+	flag.DurationVar(&timeout, "t", time.Minute, "deadline for a single search, chains that take longer are returned unfinished")
+	flag.DurationVar(&findaccount.ChainTimeout, "c", findaccount.ChainTimeout, "deadline for searching a single chain")
+	flag.IntVar(&findaccount.Concurrency, "j", findaccount.Concurrency, "how many chains a single search queries at the same time")
+	flag.StringVar(&registry, "registry", "", "optional: chain-registry directory to use instead of the built-in one")
 	flag.Parse()
 
-	invalidRequest := []byte(`{"error":"invalid request"}`)
-	invalidResponse := []byte(`"error":"unknown server error"`)
+	if registry != "" {
+		err := chaininfo.Load(os.DirFS(registry))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// RPC clients are kept between requests and shared by all of them
+	findaccount.SetPool(client.NewPool(revalidate))
 
-	http.HandleFunc("/q", func(writer http.ResponseWriter, request *http.Request) {
+	http.HandleFunc("/q", queryHandler(timeout, xForwarded, useXForwarded))
+	http.Handle("/", &CacheHandler{})
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
+}
+
+var (
+	invalidRequest  = []byte(`{"error":"invalid request"}`)
+	invalidResponse = []byte(`"error":"unknown server error"`)
+)
+
+// queryHandler searches the address or public key given in the addr or pubkey parameter and replies with
+// the results as JSON. Each search is given timeout to finish.
+func queryHandler(timeout time.Duration, xForwarded string, useXForwarded bool) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
 		remoteIp := request.RemoteAddr
 
 		log := func(msg string) {
@@ -43,23 +75,43 @@ func main() {
 			}
 		}
 
+		// the search stops when the client goes away or the deadline passes
+		ctx, cancel := context.WithTimeout(request.Context(), timeout)
+		defer cancel()
+
+		var result []findaccount.ChainResult
+		var err error
 		addr := request.URL.Query()["addr"]
-		if addr == nil || len(addr) == 0 {
+		pubkey := request.URL.Query()["pubkey"]
+		switch {
+		case len(addr) > 0:
+			// ensure a valid addr before continuing
+			_, _, _, err = findaccount.DecodeAddress(addr[0])
+			if err != nil {
+				//writer.WriteHeader(http.StatusBadRequest)
+				_, _ = writer.Write(invalidRequest)
+				log(fmt.Sprintf("could not decode address %q", addr[0]))
+				return
+			}
+			result, err = findaccount.SearchAccounts(ctx, addr[0], "", "", "")
+		case len(pubkey) > 0:
+			var key []byte
+			key, err = findaccount.ParsePubKey(pubkey[0])
+			if err != nil {
+				//writer.WriteHeader(http.StatusBadRequest)
+				_, _ = writer.Write(invalidRequest)
+				log(fmt.Sprintf("could not decode public key %q", pubkey[0]))
+				return
+			}
+			result, err = findaccount.SearchPubKey(ctx, key)
+		default:
 			//writer.WriteHeader(http.StatusBadRequest)
 			_, _ = writer.Write(invalidRequest)
 			return
 		}
-
-		// ensure a valid addr before continuing
-		_, _, err := bech32.DecodeAndConvert(addr[0])
-		if err != nil {
-			//writer.WriteHeader(http.StatusBadRequest)
-			_, _ = writer.Write(invalidRequest)
-			log(fmt.Sprintf("could not decode bech32 address %q", addr[0]))
-			return
+		if e := client.SaveHealth(); e != nil {
+			log("could not save endpoint health: " + e.Error())
 		}
-
-		result, err := findaccount.SearchAccounts(addr[0])
 		if err != nil {
 			writer.WriteHeader(http.StatusInternalServerError)
 			_, _ = writer.Write(invalidResponse)
@@ -82,20 +134,13 @@ func main() {
 		}
 
 		_, _ = writer.Write(body)
-	})
-
-	http.Handle("/", &CacheHandler{})
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
+	}
 }
 
 // CacheHandler implements the Handler interface with a very long Cache-Control set on responses
 type CacheHandler struct{}
 
 func (ch CacheHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
-	rootDir, err := fs.Sub(findaccount.StaticFs, "static")
-	if err != nil {
-		log.Fatalln(err)
-	}
 	writer.Header().Set("Cache-Control", "public, max-age=86400")
-	http.FileServer(http.FS(rootDir)).ServeHTTP(writer, request)
+	http.FileServer(http.FS(static.Fs)).ServeHTTP(writer, request)
 }
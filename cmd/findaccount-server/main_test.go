@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	findaccount "github.com/johnsaigle/findaccount/pkg/account"
+	"github.com/johnsaigle/findaccount/pkg/chaininfo"
+	"github.com/johnsaigle/findaccount/pkg/client"
+	"github.com/johnsaigle/findaccount/pkg/mockrpc"
+)
+
+func get(t *testing.T, handler http.Handler, query url.Values, header http.Header) (int, []byte) {
+	t.Helper()
+	request := httptest.NewRequest(http.MethodGet, "/q?"+query.Encode(), nil)
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	body, err := io.ReadAll(recorder.Result().Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return recorder.Code, body
+}
+
+func TestQueryHandler(t *testing.T) {
+	network, err := mockrpc.StartDefault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer network.Close()
+	err = chaininfo.Load(network.Registry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HealthFile = ""
+	findaccount.SetPool(client.NewPool(client.DefaultRevalidateInterval))
+
+	handler := queryHandler(time.Minute, "X-Forwarded-For", false)
+
+	code, body := get(t, handler, url.Values{"addr": {"cosmos1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twlpvf6m"}}, nil)
+	if code != http.StatusOK {
+		t.Fatalf("got status %d: %s", code, body)
+	}
+	var results []findaccount.ChainResult
+	err = json.Unmarshal(body, &results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want cosmoshub, osmosis and the interchain account on osmosis", len(results))
+	}
+	for _, r := range results {
+		// results without errors are sent with an empty error
+		if !r.Exists || r.Error != "" || r.TimedOut {
+			t.Errorf("got %s result %+v", r.Chain, r)
+		}
+	}
+	if hub := results[0]; hub.Chain != "cosmoshub" || hub.Validator == nil || hub.Validator.Moniker != "Example" || hub.Coins.String() != "2.5 OSMO; 37,256.755969 ATOM" {
+		t.Errorf("got cosmoshub result %+v", hub)
+	}
+
+	for name, query := range map[string]url.Values{
+		"no parameters":   {},
+		"invalid address": {"addr": {"cosmos1notanaddress"}},
+		"invalid pubkey":  {"pubkey": {"not a key"}},
+	} {
+		code, body = get(t, handler, query, nil)
+		if code != http.StatusOK || string(body) != string(invalidRequest) {
+			t.Errorf("%s: got status %d and %s", name, code, body)
+		}
+	}
+
+	proxied := queryHandler(time.Minute, "X-Forwarded-For", true)
+	code, _ = get(t, proxied, url.Values{"addr": {"cosmos1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twlpvf6m"}}, http.Header{"X-Forwarded-For": {"not an ip"}})
+	if code != http.StatusInternalServerError {
+		t.Errorf("got status %d for an invalid X-Forwarded-For header", code)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/johnsaigle/findaccount/pkg/mockrpc"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+)
+
+// mock-rpc serves the chains of a fixture on local RPC endpoints and writes a chain-registry pointing at
+// them, so findaccount and findaccount-server can be run offline with --registry
+func main() {
+	var fixturePath string
+	var dir string
+
+	flag.StringVar(&fixturePath, "f", "", "fixture file describing the chains, the built-in fixture when empty")
+	flag.StringVar(&dir, "o", "", "directory to write the chain-registry to, a temporary directory when empty")
+	flag.Parse()
+
+	var network *mockrpc.Network
+	var err error
+	if fixturePath == "" {
+		network, err = mockrpc.StartDefault()
+	} else {
+		var fixture *mockrpc.Fixture
+		fixture, err = mockrpc.ReadFixture(fixturePath)
+		if err == nil {
+			network, err = mockrpc.Start(fixture)
+		}
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer network.Close()
+
+	if dir == "" {
+		dir, err = os.MkdirTemp("", "chain-registry")
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer os.RemoveAll(dir)
+	}
+	err = network.WriteRegistry(dir)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	names := make([]string, 0, len(network.Servers))
+	for name := range network.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%-14s: %s\n", name, network.Servers[name].URL)
+	}
+	fmt.Printf("registry written to %s, search it with --registry %s\n", dir, dir)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	<-ctx.Done()
+}
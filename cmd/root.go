@@ -1,40 +1,211 @@
 package cmd
 
 import (
+  "context"
+  "errors"
   "fmt"
+  "io"
   "os"
+  "os/signal"
+  "path/filepath"
   "log"
+  "time"
 
   "github.com/spf13/cobra"
   account "github.com/johnsaigle/findaccount/pkg/account"
+  "github.com/johnsaigle/findaccount/pkg/chaininfo"
+  "github.com/johnsaigle/findaccount/pkg/client"
 )
 
 var (
   address string
+  pubkey string
   name string
   prefix string
   rpc string
+  exists bool
+  batch string
+  timeout time.Duration
+  chainTimeout time.Duration
+  concurrency int
+  registry string
+  record string
+  replay string
 )
 
 var rootCmd = &cobra.Command{
   Use:   "findaccount",
   Short: "Find accounts across the Cosmoverse",
-  Long: `Supply a bech32 Cosmos address and discover other chains for which the same address exists.
-  The tool will also report whether the address is a validator and what tokens it has in its accounts across different chains.`,
-  Run: func(cmd *cobra.Command, args []string) {
-    results, err := account.SearchAccounts(address, name, rpc, prefix)
+  Long: `Supply a bech32 (or hex/0x) Cosmos address and discover other chains for which the same address exists.
+  The tool will also report whether the address is a validator and what tokens it has in its accounts across different chains.
+  Supply a public key instead of an address to also find accounts on chains that derive addresses differently (e.g. coin type 60).
+  Supply a file (or - for stdin) with --batch to search many addresses, one per line or as label,address CSV records.`,
+  Args: func(cmd *cobra.Command, args []string) error {
+    if address == "" && pubkey == "" && batch == "" {
+      return errors.New("one of --address, --pubkey or --batch is required")
+    }
+    if batch != "" && name != "" {
+      return errors.New("--batch cannot be combined with a custom RPC endpoint")
+    }
+    if pubkey != "" && name != "" {
+      return errors.New("--pubkey cannot be combined with a custom RPC endpoint")
+    }
+    return nil
+  },
+  PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+    if registry == "" {
+      return nil
+    }
+    return chaininfo.Load(os.DirFS(registry))
+  },
+  // errors are returned rather than fatal so the recording is still saved
+  RunE: func(cmd *cobra.Command, args []string) error {
+    cmd.SilenceUsage, cmd.SilenceErrors = true, true
+    ctx, cancel := searchContext()
+    defer cancel()
+    defer saveHealth()
+    save := startCapture()
+    defer save()
+    if batch != "" {
+      return runBatch(ctx)
+    }
+    var results []account.ChainResult
+    var err error
+    if pubkey != "" {
+      var key []byte
+      key, err = account.ParsePubKey(pubkey)
+      if err != nil {
+        return err
+      }
+      results, err = account.SearchPubKey(ctx, key)
+    } else {
+      results, err = account.SearchAccounts(ctx, address, name, rpc, prefix)
+    }
     if err != nil {
       log.Println(err)
     }
+    if exists {
+      active := results[:0]
+      for _, r := range results {
+        if r.Exists {
+          active = append(active, r)
+        }
+      }
+      results = active
+    }
     if len(results) > 0 {
       fmt.Println(results[0].CsvHeader())
       for _, r := range results {
         fmt.Println(r.ToCsv())
       }
     }
+    return nil
   },
 }
 
+// searchContext is cancelled by Ctrl-C or when the --timeout deadline passes. Chains that are still being
+// searched at that point are reported with what was found so far. In batch mode the deadline applies to
+// each address instead, so a long batch is not cut short.
+func searchContext() (context.Context, context.CancelFunc) {
+  account.ChainTimeout = chainTimeout
+  account.Concurrency = concurrency
+  ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+  if batch != "" {
+    account.AddressTimeout = timeout
+    return ctx, stop
+  }
+  if timeout <= 0 {
+    return ctx, stop
+  }
+  ctx, cancel := context.WithTimeout(ctx, timeout)
+  return ctx, func() {
+    cancel()
+    stop()
+  }
+}
+
+// startCapture switches searches to record or replay mode when --record or --replay is given. The returned
+// function saves the recording once the search is done.
+func startCapture() func() {
+  switch {
+  case record != "":
+    recorder, err := client.NewRecorder(record)
+    if err != nil {
+      log.Fatalln(err)
+    }
+    // the chains and assets are kept with the recording, so replaying does not depend on the registry
+    // built into whoever replays it
+    err = chaininfo.Write(filepath.Join(record, "registry"))
+    if err != nil {
+      log.Fatalln(err)
+    }
+    account.Record(recorder)
+    return func() {
+      if err := recorder.Save(); err != nil {
+        log.Fatalln(err)
+      }
+    }
+  case replay != "":
+    replayer, err := client.NewReplayer(replay)
+    if err != nil {
+      log.Fatalln(err)
+    }
+    if registry == "" {
+      err = chaininfo.Load(os.DirFS(filepath.Join(replay, "registry")))
+      if err != nil {
+        log.Fatalln(err)
+      }
+    }
+    account.Replay(replayer)
+  }
+  return func() {}
+}
+
+// saveHealth keeps the endpoint rankings probed during the run for the next one
+func saveHealth() {
+  if err := client.SaveHealth(); err != nil {
+    // a ranking that cannot be saved was still good for this run
+    log.Println("could not save endpoint health:", err)
+  }
+}
+
+// runBatch streams the results of every address in the batch file as CSV, with the label of the input
+// address as the first column
+func runBatch(ctx context.Context) error {
+  var in io.Reader = os.Stdin
+  if batch != "-" {
+    f, err := os.Open(batch)
+    if err != nil {
+      return err
+    }
+    defer f.Close()
+    in = f
+  }
+  entries, err := account.ReadBatch(in)
+  if err != nil {
+    return err
+  }
+
+  fmt.Println("label," + account.ChainResult{}.CsvHeader())
+  err = account.SearchBatch(ctx, entries, func(b account.BatchResult) {
+    if b.Error != "ok" {
+      log.Printf("%s: %s\n", b.Label, b.Error)
+    }
+    for _, r := range b.Results {
+      if exists && !r.Exists {
+        continue
+      }
+      fmt.Printf("%q,%s\n", b.Label, r.ToCsv())
+    }
+  })
+  // entries that were not searched have been reported already
+  if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+    log.Println(err)
+    return nil
+  }
+  return err
+}
+
 func Execute() {
   // https://github.com/spf13/cobra/blob/main/user_guide.md
   if err := rootCmd.Execute(); err != nil {
@@ -44,12 +215,22 @@ func Execute() {
 }
 
 func init() {
-  rootCmd.Flags().StringVarP(&address, "address", "a", "", "A bech32-encoded address")
+  rootCmd.Flags().StringVarP(&address, "address", "a", "", "A bech32, hex or 0x address")
+  rootCmd.Flags().StringVarP(&pubkey, "pubkey", "k", "", "A secp256k1 public key as hex, base64 or Any JSON")
   rootCmd.Flags().StringVarP(&rpc, "rpc", "r", "", "The fully-qualified URL for the custom RPC endpoint")
   rootCmd.Flags().StringVarP(&prefix, "prefix", "f", "", "The bech32 prefix for the chain")
   rootCmd.Flags().StringVarP(&name, "name", "n", "", "The name of the chain")
+  rootCmd.Flags().StringVarP(&batch, "batch", "b", "", "A file of addresses to search, one per line or as label,address CSV. Use - for stdin")
+  rootCmd.PersistentFlags().DurationVarP(&timeout, "timeout", "t", 2*time.Minute, "Deadline for the whole search, or for each address with --batch, 0 for none")
+  rootCmd.PersistentFlags().DurationVar(&chainTimeout, "chain-timeout", account.ChainTimeout, "Deadline for searching a single chain")
+  rootCmd.PersistentFlags().IntVar(&concurrency, "concurrency", account.Concurrency, "How many chains to search at the same time")
+  rootCmd.PersistentFlags().StringVar(&registry, "registry", "", "A chain-registry directory to use instead of the built-in one")
+  rootCmd.Flags().StringVar(&record, "record", "", "Save every query sent during the search to a directory, for --replay")
+  rootCmd.Flags().StringVar(&replay, "replay", "", "Answer queries from a directory saved with --record instead of the network")
+  rootCmd.Flags().BoolVarP(&exists, "exists", "e", false, "Only list chains where the address has ever been active, not just currently funded")
   // TODO: also a custom block explorer?
-  rootCmd.MarkFlagRequired("address")
+  rootCmd.MarkFlagsMutuallyExclusive("address", "pubkey", "batch")
+  rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
   rootCmd.MarkFlagsRequiredTogether("rpc","name", "prefix")
 
   // rootCmd.AddCommand(searchCmd)
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+  "io"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+
+  "github.com/spf13/pflag"
+  account "github.com/johnsaigle/findaccount/pkg/account"
+  "github.com/johnsaigle/findaccount/pkg/client"
+  "github.com/johnsaigle/findaccount/pkg/mockrpc"
+)
+
+// startNetwork serves mockrpc.DefaultFixture and writes its chain-registry to a directory for --registry
+func startNetwork(t *testing.T) string {
+  t.Helper()
+  network, err := mockrpc.StartDefault()
+  if err != nil {
+    t.Fatal(err)
+  }
+  t.Cleanup(network.Close)
+  dir := t.TempDir()
+  err = network.WriteRegistry(dir)
+  if err != nil {
+    t.Fatal(err)
+  }
+  client.HealthFile = ""
+  account.SetPool(client.NewPool(client.DefaultRevalidateInterval))
+  return dir
+}
+
+// run executes the root command with args and returns the lines it printed to stdout. The flags are reset
+// first since they are kept in package variables between runs.
+func run(t *testing.T, args ...string) []string {
+  t.Helper()
+  for _, flags := range []*pflag.FlagSet{rootCmd.Flags(), rootCmd.PersistentFlags()} {
+    flags.VisitAll(func(f *pflag.Flag) {
+      _ = f.Value.Set(f.DefValue)
+      f.Changed = false
+    })
+  }
+
+  r, w, err := os.Pipe()
+  if err != nil {
+    t.Fatal(err)
+  }
+  stdout := os.Stdout
+  os.Stdout = w
+  out := make(chan []byte)
+  go func() {
+    b, _ := io.ReadAll(r)
+    out <- b
+  }()
+  rootCmd.SetArgs(args)
+  err = rootCmd.Execute()
+  os.Stdout = stdout
+  w.Close()
+  printed := <-out
+  if err != nil {
+    t.Fatalf("findaccount %s: %v", strings.Join(args, " "), err)
+  }
+  return strings.Split(strings.TrimSpace(string(printed)), "\n")
+}
+
+const (
+  hubRow = `cosmoshub,cosmos1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twlpvf6m,0xeE6e74038570EBC3e59aCb7e8481F11E09A6516e,secp256k1,,,true,"account; signed; balance; staked; rewards; validator","Example (bonded, #2, missed 3/100)","base #1234 seq 12",true,"2.5 OSMO; 37,256.755969 ATOM","Example: 5,000 ATOM; Other: 1 ATOM","Other: 0.5 ATOM until 2030-01-01T00:00:00Z","","15.75 ATOM","0.001234 ATOM; self-delegation: 5,000 ATOM",false,rpc,ok`
+  osmosisRow = `osmosis,osmo1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twh6levf,0xeE6e74038570EBC3e59aCb7e8481F11E09A6516e,secp256k1,,,true,"account; balance","","base #5678 seq 0",true,"119,849.309021 OSMO","","","","","",false,rpc,ok`
+  icaRow = `osmosis,osmo1fx6893deednhgupzp5p5j7awexcync08qkn5j6ndxq66sdltfzlq2pns9k,,secp256k1,ica,cosmoshub/connection-0,true,"account; signed; balance","","base #6000 seq 2",true,"5 OSMO","","","","","",false,rpc,ok`
+)
+
+func checkLines(t *testing.T, got, want []string) {
+  t.Helper()
+  if len(got) != len(want) {
+    t.Fatalf("got %d lines\n%s\nwant %d", len(got), strings.Join(got, "\n"), len(want))
+  }
+  for i := range want {
+    if got[i] != want[i] {
+      t.Errorf("line %d: got\n%s\nwant\n%s", i+1, got[i], want[i])
+    }
+  }
+}
+
+func TestRootOnMockNetwork(t *testing.T) {
+  registry := startNetwork(t)
+  header := account.ChainResult{}.CsvHeader()
+
+  lines := run(t, "--registry", registry, "-a", "cosmos1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twlpvf6m")
+  checkLines(t, lines, []string{header, hubRow, osmosisRow, icaRow})
+
+  // the second address was never active, so --exists leaves no rows for it
+  batch := filepath.Join(t.TempDir(), "batch.csv")
+  err := os.WriteFile(batch, []byte("label,address\ntreasury,cosmos1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twlpvf6m\nunused,cosmos1kzvsfy5p75tm7p7sdnsvt7lmugmputuqzgytgf\n"), 0o644)
+  if err != nil {
+    t.Fatal(err)
+  }
+  lines = run(t, "--registry", registry, "--batch", batch, "--exists")
+  checkLines(t, lines, []string{
+    "label," + header,
+    `"treasury",` + hubRow,
+    `"treasury",` + osmosisRow,
+    `"treasury",` + icaRow,
+  })
+}
@@ -1,178 +1,411 @@
 package findaccount
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/cosmos/cosmos-sdk/types/bech32"
 	"github.com/johnsaigle/findaccount/pkg/chaininfo"
 	"github.com/johnsaigle/findaccount/pkg/client"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
 )
 
-var accountsMux sync.Mutex
 var infos = chaininfo.Infos //populated by init code when the script gets run
 
+// Concurrency limits how many chains a single search queries at the same time. Searches running side by
+// side, as in the server, each get their own workers. Zero or less means no limit.
+var Concurrency = 16
+
+// ChainTimeout bounds the search of a single chain. A chain that does not finish in time is returned with
+// what was found so far and TimedOut set.
+var ChainTimeout = 30 * time.Second
+
+// pool holds the RPC clients shared by every search in the process, see SetPool
+var pool = client.NewPool(client.DefaultRevalidateInterval)
+
+// QuerierFunc returns the ChainQuerier a search uses for a chain of the chain-registry. By default it
+// wraps the pooled client of the chain, see SetQuerierFunc.
+type QuerierFunc func(ctx context.Context, chain string) (client.ChainQuerier, error)
+
+var querierFor QuerierFunc = func(ctx context.Context, chain string) (client.ChainQuerier, error) {
+	transport, err := connect(ctx, chain, "")
+	if err != nil {
+		return nil, err
+	}
+	return client.NewQuerier(chain, transport), nil
+}
+
+// SetQuerierFunc replaces how searches reach the chains of the chain-registry, e.g. with in-memory
+// queriers so the search can run without network access. It should be called before the first search.
+func SetQuerierFunc(f QuerierFunc) {
+	querierFor = f
+}
+
+// recorder and replayer are set in record and replay mode, see Record and Replay
+var (
+	recorder *client.Recorder
+	replayer *client.Replayer
+)
+
+// Record makes searches capture every query they send into r. The caller saves the recording once the
+// searches are done.
+func Record(r *client.Recorder) {
+	recorder = r
+}
+
+// Replay makes searches answer their queries from the recording in r instead of the network
+func Replay(r *client.Replayer) {
+	replayer = r
+}
+
+// connect returns the transport for chain, through the custom endpoint rpc if one is given and the pooled
+// client otherwise. In replay mode the recorded transport is returned instead.
+func connect(ctx context.Context, chain, rpc string) (client.Transport, error) {
+	if replayer != nil {
+		return replayer.Transport(ctx, chain)
+	}
+	var transport client.Transport
+	var err error
+	if rpc != "" {
+		var rpcclient *rpchttp.HTTP
+		rpcclient, err = client.NewClient(ctx, rpc)
+		if err == nil {
+			transport = client.RPCTransport{Client: rpcclient}
+		}
+	} else {
+		transport, err = pool.Client(ctx, chain, infos[chain])
+	}
+	if recorder != nil {
+		return recorder.Transport(ctx, chain, transport, err)
+	}
+	return transport, err
+}
+
+// now is the time results that depend on it are computed at, the time of the recording in record and
+// replay mode
+func now() time.Time {
+	switch {
+	case replayer != nil:
+		return replayer.Time()
+	case recorder != nil:
+		return recorder.Time()
+	default:
+		return time.Now()
+	}
+}
+
+// SetPool replaces the RPC client pool used by searches, e.g. to change the revalidation interval. It
+// should be called before the first search.
+func SetPool(p *client.Pool) {
+	pool.Close()
+	pool = p
+}
+
 type ChainResult struct {
 	Chain      string `json:"chain"`
 	Address    string `json:"address"`
-	Validator  string `json:"is_validator"`
-	HasBalance bool   `json:"hasBalance"`
-	Coins      string `json:"coins"`
-	Error      string `json:"error"`
-	Link       string `json:"link"`
+	HexAddress string `json:"hex_address"`
+	Derivation string `json:"derivation"`
+	// DerivedVia is set when the address was not derived from the input but found through it, e.g. "ica"
+	// for an interchain account. Controller then names the chain and connection that controls it.
+	DerivedVia string       `json:"derived_via,omitempty"`
+	Controller string       `json:"controller,omitempty"`
+	Validator  *Validator   `json:"validator"`
+	Account    *AccountInfo `json:"account"`
+	Exists     bool         `json:"exists"`
+	Activity   []string     `json:"activity"`
+	HasBalance bool         `json:"hasBalance"`
+	Coins      Coins        `json:"coins"`
+	Staking    Staking      `json:"staking"`
+	Rewards    Rewards      `json:"rewards"`
+	// InterchainAccounts are the accounts this address controls on other chains over IBC
+	InterchainAccounts []InterchainAccount `json:"interchain_accounts,omitempty"`
+	// TimedOut is set when the search of the chain was cut short by a deadline or cancellation, in which
+	// case the other fields only hold what was found before
+	TimedOut bool `json:"timed_out"`
+	// Transport is the kind of endpoint the chain was searched through, rpc, grpc or rest, and Endpoint
+	// its address
+	Transport string `json:"transport"`
+	Endpoint  string `json:"endpoint"`
+	Error     string `json:"error"`
+	Link      string `json:"link"`
 }
 
 func (r ChainResult) CsvHeader() string {
-	return "chain,address,validator,has balance,coins,error"
+	return "chain,address,hex address,derivation,derived via,controller,exists,activity,validator,account,has balance,coins,delegations,unbonding,redelegations,rewards,commission,timed out,transport,error"
 }
 
 func (r ChainResult) ToCsv() string {
-	return fmt.Sprintf("%s,%s,%q,%v,%s,%s", r.Chain, r.Address, r.Validator, r.HasBalance, r.Coins, r.Error)
+	return fmt.Sprintf("%s,%s,%s,%s,%s,%s,%v,%q,%q,%q,%v,%q,%q,%q,%q,%q,%q,%v,%s,%s", r.Chain, r.Address, r.HexAddress, r.Derivation, r.DerivedVia, r.Controller, r.Exists, r.ActivityString(), r.Validator.String(), r.Account.String(), r.HasBalance, r.Coins.String(),
+		r.Staking.DelegationsString(), r.Staking.UnbondingString(), r.Staking.RedelegationsString(),
+		r.Rewards.String(), r.Rewards.CommissionString(), r.TimedOut, r.Transport, r.Error)
+}
+
+// appendError adds err to an error string that is either "ok" or holds earlier errors
+func appendError(errStr string, err error) string {
+	if errStr == "" || errStr == "ok" {
+		return err.Error()
+	}
+	return errStr + "; " + err.Error()
+}
+
+// joinErrors combines errs into a single error whose message lists them as appendError does, or returns
+// nil when there are none
+func joinErrors(errs []error) error {
+	var joined joinedError
+	for _, err := range errs {
+		if err != nil {
+			joined = append(joined, err)
+		}
+	}
+	if len(joined) == 0 {
+		return nil
+	}
+	return joined
+}
+
+// joinedError keeps the errors it joins, so errors.Is and errors.As still see them
+type joinedError []error
+
+func (e joinedError) Error() string {
+	msg := ""
+	for _, err := range e {
+		msg = appendError(msg, err)
+	}
+	return msg
+}
+
+func (e joinedError) Unwrap() []error {
+	return e
 }
 
 // SearchAccounts is the entrypoint for performing a search
-func SearchAccounts(account, name, rpc, prefix string) ([]ChainResult, error) {
+func SearchAccounts(ctx context.Context, account, name, rpc, prefix string) ([]ChainResult, error) {
 	// TODO : validate rpc and prefix
 	// i.e. if prefix is not alphanumeric
 	// i.e. if rpc is not well-formed (may need a URL-parsing library
 	results := make([]ChainResult, 0)
-	var addrMap map[string]string
-	var err error
 
 	if name != "" && rpc != "" && prefix != "" {
-		addrMap, err = ConvertToAccountCustom(account, name, rpc, prefix)
+		addrMap, err := ConvertToAccountCustom(account, name, rpc, prefix)
 		if err != nil {
 			return results, err
 		}
-		rpcclient, err := client.NewClient(rpc)
+		ctx, cancel := context.WithTimeout(ctx, ChainTimeout)
+		defer cancel()
+		ctx = client.WithScope(ctx, addrMap[name])
+		transport, err := connect(ctx, name, rpc)
 		if err != nil {
 			return results, err
 		}
-		bal, coins, err:= client.QueryAccount(*rpcclient, addrMap[name])
-		val, _ := client.IsValidator(*rpcclient, addrMap[name], prefix)
-		link := "not implemented!" // TODO add this
-		var errString string
-		if err != nil {
-			errString = err.Error()
-		}
-		results = append(results, ChainResult{
-			Chain: name,
-			Address: addrMap[name],
-			Validator: val,
-			HasBalance: bal,
-			Coins: coins,
-			Error: errString,
-			Link: link,
-		})
-		return results, nil
-	}
-	addrMap, err = ConvertToAccounts(account)
-	if err != nil {
-		return results, err
+		q := client.NewQuerier(name, transport)
+		result := queryChain(ctx, q, ChainResult{
+			Chain:      name,
+			Address:    addrMap[name],
+			HexAddress: hexAddress(addrMap[name]),
+			Derivation: keyAlgoForPrefix(prefix),
+			Error:      "ok",
+			Link:       "not implemented!", // TODO add this
+		}, prefix)
+		markUnfinished(&result, ctx.Err())
+		result.setActivity()
+		return append(results, result), nil
 	}
 
-	wg := &sync.WaitGroup{}
-	wg.Add(len(infos))
-	for k, v := range infos {
-		var link string
-
-		accountsMux.Lock()
-		// chain, rpcs := k, v
-		chain, _ := k, v
-		addr := addrMap[k]
-		if len(infos[k].Explorers) > 0 {
-			link = infos[k].Explorers[0].Url
-		}
-		accountsMux.Unlock()
+	return searchAddress(ctx, account)
+}
 
+// searchJob is a single address to look up on a single chain
+type searchJob struct {
+	chain    string
+	addr     string
+	keyAlgo  string
+	mismatch bool
+	// derivedVia and controller are copied onto the result, see ChainResult
+	derivedVia string
+	controller string
+}
+
+// fanOut searches the jobs with at most Concurrency workers and returns the results sorted by chain.
+// Every job yields a result, even once ctx has ended.
+func fanOut(ctx context.Context, jobs []searchJob) []ChainResult {
+	workers := Concurrency
+	if workers <= 0 || workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	queue := make(chan searchJob)
+	found := make(chan ChainResult, len(jobs))
+	wg := &sync.WaitGroup{}
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
 		go func() {
-			errStr := "ok"
-			rpcclient, err := client.NewClientFromChainInfo(infos[chain].Apis.Rpc, chain)
-			if err != nil {
-				err = fmt.Errorf("Could not build RPC client: %w", err)
-				results = append(results, ChainResult{
-					Chain:      chain,
-					Address:    addr,
-					Validator:  "N/A",
-					HasBalance: false,
-					Coins:      "N/A",
-					Error:      err.Error(),
-					Link:       link,
-				})
-				wg.Done()
-				return
-			}
-			bal, coins, err := client.QueryAccount(*rpcclient, addr)
-			if err != nil {
-				results = append(results, ChainResult{
-					Chain:      chain,
-					Address:    addr,
-					Validator:  "N/A",
-					HasBalance: false,
-					Coins:      "N/A",
-					Error:      err.Error(),
-					Link:       link,
-				})
-				wg.Done()
-				return
+			defer wg.Done()
+			for job := range queue {
+				found <- searchChain(ctx, job)
 			}
-			val, err := client.IsValidator(*rpcclient, addr, infos[chain].Bech32Prefix)
-			if err != nil {
-				errStr = err.Error()
-			}
-			results = append(results, ChainResult{
-				Chain:      chain,
-				Address:    addr,
-				Validator:  val,
-				HasBalance: bal,
-				Coins:      coins,
-				Error:      errStr,
-				Link:       link,
-			})
-			wg.Done()
 		}()
 	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
 	wg.Wait()
+	close(found)
 
+	results := make([]ChainResult, 0, len(jobs))
+	for result := range found {
+		result.setActivity()
+		results = append(results, result)
+	}
 	sort.Slice(results, func(i, j int) bool {
-		return sort.StringsAreSorted([]string{results[i].Chain, results[j].Chain})
+		if results[i].Chain != results[j].Chain {
+			return results[i].Chain < results[j].Chain
+		}
+		if results[i].Derivation != results[j].Derivation {
+			return results[i].Derivation < results[j].Derivation
+		}
+		return results[i].Address < results[j].Address
 	})
 
-	return results, err
+	return results
 }
 
-// Takes a string that should be a bech32 address. Returns error if it isn't 
-// Extracts the bytes that represent the actual address (without HRP and checksum)
-// Iterates over the ChainInfo struct to obtain all bech32 prefixes extract from the chain-registry.
-// Encode the address bytes using all bech32 prefixes
-// Returns a mapping of chain names to generated addresses
-func ConvertToAccounts(s string) (map[string]string, error) {
-	accounts := make(map[string]string)
-	_, b64, err := bech32.DecodeAndConvert(s)
+// searchChain takes the querier of the chain and runs every query for the job, giving up after
+// ChainTimeout
+func searchChain(ctx context.Context, job searchJob) (result ChainResult) {
+	result = ChainResult{
+		Chain:      job.chain,
+		Address:    job.addr,
+		HexAddress: hexAddress(job.addr),
+		Derivation: job.keyAlgo,
+		DerivedVia: job.derivedVia,
+		Controller: job.controller,
+		Error:      "ok",
+	}
+	if len(infos[job.chain].Explorers) > 0 {
+		result.Link = infos[job.chain].Explorers[0].Url
+	}
+	if job.mismatch {
+		result.Error = fmt.Sprintf("chain derives addresses with %s keys, search by public key to find this account", job.keyAlgo)
+		return result
+	}
 
+	ctx, cancel := context.WithTimeout(ctx, ChainTimeout)
+	defer cancel()
+	// searches of other addresses on the same chain are recorded apart from this one
+	ctx = client.WithScope(ctx, job.addr)
+	defer func() {
+		markUnfinished(&result, ctx.Err())
+	}()
+	q, err := querierFor(ctx, job.chain)
 	if err != nil {
-		return nil, err
+		// a client that could not be built in time is reported as unfinished
+		if cause := contextErr(ctx, err); cause != nil {
+			markUnfinished(&result, cause)
+		} else {
+			result.Error = fmt.Errorf("Could not build client: %w", err).Error()
+		}
+		return result
 	}
+	return queryChain(ctx, q, result, infos[job.chain].Bech32Prefix)
+}
 
-	for name, chainInfo := range infos {
-		addr, e := bech32.ConvertAndEncode(chainInfo.Bech32Prefix, b64)
-		if e != nil {
-			log.Println(name, e)
-		}
-		accounts[name] = addr
+// markUnfinished flags result as timed out when cause, the error of the context the search ran under, is
+// set, whether the context ended by its deadline or by cancellation. A result is only flagged once.
+func markUnfinished(result *ChainResult, cause error) {
+	switch {
+	case cause == nil || result.TimedOut:
+		return
+	case errors.Is(cause, context.DeadlineExceeded):
+		result.Error = appendError(result.Error, errors.New("timed out"))
+	default:
+		result.Error = appendError(result.Error, errors.New("canceled"))
 	}
+	result.TimedOut = true
+}
 
-	return accounts, nil
+// contextErr returns the error of ctx once it has ended. A query can also fail with the error of a
+// context that ended elsewhere, e.g. one replayed from a recording where it timed out, which is returned
+// as well. Other errors give nil.
+func contextErr(ctx context.Context, err error) error {
+	if e := ctx.Err(); e != nil {
+		return e
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+// queryChain fills in result for the chain and address it names. A failed balance query ends the search
+// for the chain; failures of the other queries are collected in the Error field. Once ctx ends every
+// remaining query would fail the same way, so the search stops there and the timeout is reported once.
+func queryChain(ctx context.Context, q client.ChainQuerier, result ChainResult, prefix string) ChainResult {
+	chain, addr := result.Chain, result.Address
+	result.Transport, result.Endpoint = q.Transport(), q.Endpoint()
+
+	coins, err := q.Balances(ctx, addr)
+	if cause := contextErr(ctx, err); cause != nil {
+		markUnfinished(&result, cause)
+		return result
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	traces := q.DenomTraces(ctx, coins)
+	result.HasBalance = !coins.IsZero()
+	result.Coins = toCoins(chain, coins, traces)
+
+	// stop records err and reports whether the search has to end
+	stop := func(err error) bool {
+		if cause := contextErr(ctx, err); cause != nil {
+			markUnfinished(&result, cause)
+			return true
+		}
+		if err != nil {
+			result.Error = appendError(result.Error, err)
+		}
+		return false
+	}
+	result.Validator, err = queryValidator(ctx, q, chain, addr, prefix)
+	if stop(err) {
+		return result
+	}
+	result.Staking, err = queryStaking(ctx, q, chain, addr)
+	if stop(err) {
+		return result
+	}
+	result.Rewards, err = queryRewards(ctx, q, chain, addr, prefix, result.Validator != nil)
+	if stop(err) {
+		return result
+	}
+	result.Account, err = queryAccountInfo(ctx, q, chain, addr)
+	if stop(err) {
+		return result
+	}
+	// registering an interchain account takes a signed transaction, so addresses that never signed one
+	// cannot own any
+	if result.Account != nil && result.Account.Sequence > 0 && result.DerivedVia == "" {
+		result.InterchainAccounts, err = queryInterchainAccounts(ctx, q, addr)
+		stop(err)
+	}
+	return result
 }
 
-// ConvertToAccounts using a custom RPC endpoint
-// encode into the same format even though there is on ly one entry 
-// so it can be processed using the same logic 
+// ConvertToAccountCustom re-encodes s for a single chain reached through a custom RPC endpoint. Searches of
+// the chain-registry use ConvertToAddressFamilies or DeriveAccounts instead.
+// encode into the same format even though there is on ly one entry
+// so it can be processed using the same logic
 func ConvertToAccountCustom(s, name, rpc, prefix string) (map[string]string, error) {
 	accounts := make(map[string]string)
-	_, b64, err := bech32.DecodeAndConvert(s)
+	_, b64, _, err := DecodeAddress(s)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,293 @@
+package findaccount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	staketypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/johnsaigle/findaccount/pkg/chaininfo"
+	"github.com/johnsaigle/findaccount/pkg/client"
+	"github.com/johnsaigle/findaccount/pkg/mockrpc"
+)
+
+const (
+	testAddress = "cosmos1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twlpvf6m"
+	testValoper = "cosmosvaloper1aeh8gqu9wr4u8ev6edlgfq03rcy6v5tw64cukg"
+)
+
+// testRegistry lists the chains searched by the tests. The endpoints are never contacted, the chains are
+// answered by the queriers passed to useQueriers.
+var testRegistry = fstest.MapFS{
+	"cosmoshub/chain.json": {Data: []byte(`{"chain_name":"cosmoshub","bech32_prefix":"cosmos","slip44":118,"apis":{"rpc":[{"address":"http://127.0.0.1:1"}]}}`)},
+	"evmos/chain.json":     {Data: []byte(`{"chain_name":"evmos","bech32_prefix":"evmos","slip44":60,"key_algos":["ethsecp256k1"],"apis":{"rpc":[{"address":"http://127.0.0.1:1"}]}}`)},
+}
+
+// useQueriers loads testRegistry and answers every chain with its querier in queriers, or with err when
+// it has none
+func useQueriers(t *testing.T, queriers map[string]client.ChainQuerier, err error) {
+	t.Helper()
+	if e := chaininfo.Load(testRegistry); e != nil {
+		t.Fatal(e)
+	}
+	client.HealthFile = ""
+	saved := querierFor
+	t.Cleanup(func() { querierFor = saved })
+	SetQuerierFunc(func(ctx context.Context, chain string) (client.ChainQuerier, error) {
+		if q, ok := queriers[chain]; ok {
+			return q, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New(chain + " has no querier")
+	})
+}
+
+// testChain builds the in-memory state of a cosmoshub chain from the fixture format of mockrpc
+func testChain(t *testing.T, chain mockrpc.Chain) *client.MemQuerier {
+	t.Helper()
+	chain.Name, chain.Bech32Prefix, chain.BondDenom = "cosmoshub", "cosmos", "uatom"
+	q, err := chain.Querier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+// failingBalances is a chain whose balance query fails
+type failingBalances struct {
+	*client.MemQuerier
+}
+
+func (failingBalances) Balances(ctx context.Context, address string) (sdk.Coins, error) {
+	return nil, errors.New("query failed: internal error")
+}
+
+// replayedTimeout is a chain whose delegations query timed out when it was recorded
+type replayedTimeout struct {
+	*client.MemQuerier
+}
+
+func (replayedTimeout) Delegations(ctx context.Context, delegator string) ([]staketypes.DelegationResponse, error) {
+	return nil, fmt.Errorf("/cosmos.staking.v1beta1.Query/DelegatorDelegations: %w", context.DeadlineExceeded)
+}
+
+func TestSearchChain(t *testing.T) {
+	validator := mockrpc.Validator{OperatorAddress: testValoper, Moniker: "Example", Tokens: 5000000000, MissedBlocks: 3}
+	withoutSigningInfo := testChain(t, mockrpc.Chain{Validators: []mockrpc.Validator{validator}})
+	for consAddress := range withoutSigningInfo.SigningInfos {
+		delete(withoutSigningInfo.SigningInfos, consAddress)
+	}
+	badConsensusKey := testChain(t, mockrpc.Chain{Validators: []mockrpc.Validator{validator}})
+	val := badConsensusKey.Validators[testValoper]
+	// a consensus key without a type is unpacked to nothing
+	val.ConsensusPubkey = &codectypes.Any{}
+	badConsensusKey.Validators[testValoper] = val
+	// tied has as many tokens as validator and a later operator address
+	tiedValoper, err := client.ValoperAddress("cosmos1kzvsfy5p75tm7p7sdnsvt7lmugmputuqzgytgf", "cosmos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tied := mockrpc.Validator{OperatorAddress: tiedValoper, Moniker: "Tied", Tokens: validator.Tokens}
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		job       searchJob
+		querier   client.ChainQuerier
+		err       error
+		exists    bool
+		activity  string
+		validator string
+		tokens    string
+		timedOut  bool
+		error     string
+	}{
+		{
+			name:    "not found",
+			querier: testChain(t, mockrpc.Chain{}),
+			error:   "ok",
+		},
+		{
+			name:     "balance only",
+			querier:  testChain(t, mockrpc.Chain{Accounts: []mockrpc.Account{{Address: testAddress, Coins: "1000uatom"}}}),
+			exists:   true,
+			activity: "account; balance",
+			error:    "ok",
+		},
+		{
+			name:     "emptied account",
+			querier:  testChain(t, mockrpc.Chain{Accounts: []mockrpc.Account{{Address: testAddress, AccountNumber: 4, Sequence: 7}}}),
+			exists:   true,
+			activity: "account; signed",
+			error:    "ok",
+		},
+		{
+			name:      "validator",
+			querier:   testChain(t, mockrpc.Chain{Validators: []mockrpc.Validator{validator}}),
+			exists:    true,
+			activity:  "validator",
+			validator: "Example (bonded, #1, missed 3/100)",
+			tokens:    "5,000,000,000 uatom",
+			error:     "ok",
+		},
+		{
+			name:      "validator without signing info",
+			querier:   withoutSigningInfo,
+			exists:    true,
+			activity:  "validator",
+			validator: "Example (bonded, #1)",
+			tokens:    "5,000,000,000 uatom",
+			error:     "ok",
+		},
+		{
+			name:      "validator tied on tokens",
+			querier:   testChain(t, mockrpc.Chain{Validators: []mockrpc.Validator{tied, validator}}),
+			exists:    true,
+			activity:  "validator",
+			validator: "Example (bonded, #1, missed 3/100)",
+			tokens:    "5,000,000,000 uatom",
+			error:     "ok",
+		},
+		{
+			name:      "undecodable consensus key",
+			querier:   badConsensusKey,
+			exists:    true,
+			activity:  "validator",
+			validator: "Example (bonded, #1)",
+			tokens:    "5,000,000,000 uatom",
+			error:     `Could not decode consensus key of type ""`,
+		},
+		{
+			name:  "connect error",
+			err:   errors.New("could not connect to any endpoints for cosmoshub"),
+			error: "Could not build client: could not connect to any endpoints for cosmoshub",
+		},
+		{
+			name:    "balance query failed",
+			querier: failingBalances{testChain(t, mockrpc.Chain{})},
+			error:   "query failed: internal error",
+		},
+		{
+			name:  "derivation mismatch",
+			job:   searchJob{chain: "evmos", addr: "evmos1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twmaswj7", keyAlgo: "ethsecp256k1", mismatch: true},
+			error: "chain derives addresses with ethsecp256k1 keys, search by public key to find this account",
+		},
+		{
+			name:     "timed out",
+			ctx:      expired,
+			querier:  testChain(t, mockrpc.Chain{Accounts: []mockrpc.Account{{Address: testAddress, Coins: "1000uatom"}}}),
+			timedOut: true,
+			error:    "timed out",
+		},
+		{
+			name:      "timed out on replay",
+			querier:   replayedTimeout{testChain(t, mockrpc.Chain{Validators: []mockrpc.Validator{validator}})},
+			exists:    true,
+			activity:  "validator",
+			validator: "Example (bonded, #1, missed 3/100)",
+			tokens:    "5,000,000,000 uatom",
+			timedOut:  true,
+			error:     "timed out",
+		},
+		{
+			name:     "canceled",
+			ctx:      canceled,
+			querier:  testChain(t, mockrpc.Chain{Accounts: []mockrpc.Account{{Address: testAddress, Coins: "1000uatom"}}}),
+			timedOut: true,
+			error:    "canceled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queriers := map[string]client.ChainQuerier{}
+			if tt.querier != nil {
+				queriers["cosmoshub"] = tt.querier
+			}
+			useQueriers(t, queriers, tt.err)
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			job := tt.job
+			if job.chain == "" {
+				job = searchJob{chain: "cosmoshub", addr: testAddress, keyAlgo: "secp256k1"}
+			}
+
+			result := searchChain(ctx, job)
+			result.setActivity()
+			if result.Exists != tt.exists {
+				t.Errorf("exists = %v, want %v", result.Exists, tt.exists)
+			}
+			if result.ActivityString() != tt.activity {
+				t.Errorf("activity = %q, want %q", result.ActivityString(), tt.activity)
+			}
+			if result.Validator.String() != tt.validator {
+				t.Errorf("validator = %q, want %q", result.Validator.String(), tt.validator)
+			}
+			if result.Validator != nil && result.Validator.Tokens.String() != tt.tokens {
+				t.Errorf("validator tokens = %q, want %q", result.Validator.Tokens.String(), tt.tokens)
+			}
+			if result.TimedOut != tt.timedOut {
+				t.Errorf("timed out = %v, want %v", result.TimedOut, tt.timedOut)
+			}
+			if result.Error != tt.error {
+				t.Errorf("error = %q, want %q", result.Error, tt.error)
+			}
+		})
+	}
+}
+
+func TestSearchAccountsInParallel(t *testing.T) {
+	useQueriers(t, map[string]client.ChainQuerier{
+		"cosmoshub": testChain(t, mockrpc.Chain{
+			Accounts:   []mockrpc.Account{{Address: testAddress, Coins: "1000uatom", AccountNumber: 4, Sequence: 7}},
+			Validators: []mockrpc.Validator{{OperatorAddress: testValoper, Moniker: "Example", Tokens: 5000000000}},
+		}),
+	}, nil)
+	saved := Concurrency
+	t.Cleanup(func() { Concurrency = saved })
+	Concurrency = 4
+
+	const searches = 8
+	results := make([][]ChainResult, searches)
+	errs := make([]error, searches)
+	wg := &sync.WaitGroup{}
+	wg.Add(searches)
+	for i := 0; i < searches; i++ {
+		i := i
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = SearchAccounts(context.Background(), testAddress, "", "", "")
+		}()
+	}
+	wg.Wait()
+
+	for i := range results {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if len(results[i]) != 2 {
+			t.Fatalf("got %d results, want one per chain", len(results[i]))
+		}
+		hub, evmos := results[i][0], results[i][1]
+		if hub.Chain != "cosmoshub" || hub.ActivityString() != "account; signed; balance; validator" || hub.Error != "ok" {
+			t.Errorf("got cosmoshub result %+v", hub)
+		}
+		if evmos.Chain != "evmos" || evmos.Exists {
+			t.Errorf("got evmos result %+v", evmos)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package findaccount
+
+import "strings"
+
+// Activity signals, in the order they are reported
+const (
+	ActivityAccount   = "account"
+	ActivitySigned    = "signed"
+	ActivityBalance   = "balance"
+	ActivityStaked    = "staked"
+	ActivityRewards   = "rewards"
+	ActivityValidator = "validator"
+)
+
+// ActivityString joins the activity signals for CSV output
+func (r ChainResult) ActivityString() string {
+	return strings.Join(r.Activity, "; ")
+}
+
+// setActivity works out whether the address has ever been used on the chain. An account that moved all
+// of its funds out still has an x/auth record with a non-zero sequence, so a zero balance alone does
+// not mean the address is unused.
+func (r *ChainResult) setActivity() {
+	r.Activity = make([]string, 0)
+	if r.Account != nil {
+		r.Activity = append(r.Activity, ActivityAccount)
+		if r.Account.Sequence > 0 {
+			r.Activity = append(r.Activity, ActivitySigned)
+		}
+	}
+	if r.HasBalance {
+		r.Activity = append(r.Activity, ActivityBalance)
+	}
+	if !r.Staking.IsEmpty() {
+		r.Activity = append(r.Activity, ActivityStaked)
+	}
+	if len(r.Rewards.Total) > 0 || len(r.Rewards.Commission) > 0 {
+		r.Activity = append(r.Activity, ActivityRewards)
+	}
+	if r.Validator != nil {
+		r.Activity = append(r.Activity, ActivityValidator)
+	}
+	r.Exists = len(r.Activity) > 0
+}
@@ -0,0 +1,118 @@
+package findaccount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	vestingexported "github.com/cosmos/cosmos-sdk/x/auth/vesting/exported"
+	vestingtypes "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
+	"github.com/johnsaigle/findaccount/pkg/client"
+)
+
+// AccountInfo is the x/auth view of an address on a chain
+type AccountInfo struct {
+	// Type is a short name such as base, module or continuous_vesting. Unknown account types are
+	// reported by their type URL.
+	Type          string   `json:"type"`
+	TypeUrl       string   `json:"type_url"`
+	AccountNumber uint64   `json:"account_number"`
+	Sequence      uint64   `json:"sequence"`
+	PubkeyType    string   `json:"pubkey_type"`
+	ModuleName    string   `json:"module_name,omitempty"`
+	Vesting       *Vesting `json:"vesting,omitempty"`
+}
+
+type Vesting struct {
+	OriginalVesting Coins     `json:"original_vesting"`
+	Locked          Coins     `json:"locked"`
+	Vested          Coins     `json:"vested"`
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+}
+
+// String summarises the account, e.g. "continuous_vesting #1234 seq 5, 12 ATOM locked until 2024-01-01"
+func (a *AccountInfo) String() string {
+	if a == nil {
+		return ""
+	}
+	if a.Type == a.TypeUrl {
+		return a.Type
+	}
+	s := fmt.Sprintf("%s #%d seq %d", a.Type, a.AccountNumber, a.Sequence)
+	if a.ModuleName != "" {
+		s += " " + a.ModuleName
+	}
+	if a.Vesting != nil {
+		s += fmt.Sprintf(", %s locked until %s", a.Vesting.Locked, a.Vesting.EndTime.Format("2006-01-02"))
+	}
+	return s
+}
+
+// accountType maps the concrete x/auth account types onto short names
+func accountType(account authtypes.AccountI) string {
+	switch account.(type) {
+	case *authtypes.BaseAccount:
+		return "base"
+	case *authtypes.ModuleAccount:
+		return "module"
+	case *vestingtypes.ContinuousVestingAccount:
+		return "continuous_vesting"
+	case *vestingtypes.DelayedVestingAccount:
+		return "delayed_vesting"
+	case *vestingtypes.PeriodicVestingAccount:
+		return "periodic_vesting"
+	case *vestingtypes.PermanentLockedAccount:
+		return "permanent_locked"
+	default:
+		return fmt.Sprintf("%T", account)
+	}
+}
+
+// queryAccountInfo looks addr up in x/auth. A nil AccountInfo with a nil error means the chain has no
+// record of the address.
+func queryAccountInfo(ctx context.Context, q client.ChainQuerier, chain, addr string) (*AccountInfo, error) {
+	raw, account, err := q.AuthAccount(ctx, addr)
+	if errors.Is(err, client.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil || raw == nil {
+		return nil, err
+	}
+
+	info := &AccountInfo{
+		Type:    raw.TypeUrl,
+		TypeUrl: raw.TypeUrl,
+	}
+	if account == nil {
+		// not a type we can decode, e.g. an Ethermint account
+		return info, nil
+	}
+
+	info.Type = accountType(account)
+	info.AccountNumber = account.GetAccountNumber()
+	info.Sequence = account.GetSequence()
+	if pk := account.GetPubKey(); pk != nil {
+		if packed, e := codectypes.NewAnyWithValue(pk); e == nil {
+			info.PubkeyType = strings.TrimPrefix(packed.TypeUrl, "/")
+		}
+	}
+	if module, ok := account.(authtypes.ModuleAccountI); ok {
+		info.ModuleName = module.GetName()
+	}
+	if vesting, ok := account.(vestingexported.VestingAccount); ok {
+		at := now()
+		info.Vesting = &Vesting{
+			OriginalVesting: toCoins(chain, vesting.GetOriginalVesting(), nil),
+			Locked:          toCoins(chain, vesting.GetVestingCoins(at), nil),
+			Vested:          toCoins(chain, vesting.GetVestedCoins(at), nil),
+			StartTime:       time.Unix(vesting.GetStartTime(), 0).UTC(),
+			EndTime:         time.Unix(vesting.GetEndTime(), 0).UTC(),
+		}
+	}
+	return info, nil
+}
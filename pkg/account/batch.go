@@ -0,0 +1,183 @@
+package findaccount
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/johnsaigle/findaccount/types"
+)
+
+// BatchEntry is a single address to search in batch mode. Label identifies the entry in the output and
+// defaults to the address itself.
+type BatchEntry struct {
+	Label   string `json:"label"`
+	Address string `json:"address"`
+}
+
+// BatchResult holds the results for one entry of a batch search
+type BatchResult struct {
+	BatchEntry
+	Results []ChainResult `json:"results"`
+	Error   string        `json:"error"`
+}
+
+// ReadBatch reads the addresses to search from r. Every line is either a bare address, or a CSV record
+// with a label and an address. A header row naming "label" and "address" columns may select other
+// columns, otherwise the label is the first column and the address the second. Empty lines and lines
+// starting with # are skipped.
+func ReadBatch(r io.Reader) ([]BatchEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	entries := make([]BatchEntry, 0)
+	labelCol, addrCol := 0, 1
+	first := true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Could not read batch input: %w", err)
+		}
+		if first {
+			first = false
+			if l, a, ok := batchHeader(record); ok {
+				labelCol, addrCol = l, a
+				continue
+			}
+		}
+
+		switch {
+		case len(record) == 1:
+			addr := strings.TrimSpace(record[0])
+			entries = append(entries, BatchEntry{Label: addr, Address: addr})
+		case len(record) > labelCol && len(record) > addrCol:
+			addr := strings.TrimSpace(record[addrCol])
+			label := strings.TrimSpace(record[labelCol])
+			if label == "" {
+				label = addr
+			}
+			entries = append(entries, BatchEntry{Label: label, Address: addr})
+		default:
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: expected a label and an address", line)
+		}
+	}
+	return entries, nil
+}
+
+// batchHeader finds the label and address columns in a header row
+func batchHeader(record []string) (labelCol, addrCol int, ok bool) {
+	labelCol, addrCol = -1, -1
+	for i, field := range record {
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "label":
+			labelCol = i
+		case "address":
+			addrCol = i
+		}
+	}
+	if addrCol < 0 {
+		return 0, 1, false
+	}
+	if labelCol < 0 {
+		labelCol = addrCol
+	}
+	return labelCol, addrCol, true
+}
+
+// batchKey identifies the key behind an address: the same 20 bytes searched from the same kind of chain
+// give the same results whatever prefix or encoding they were written in
+func batchKey(addr string) (string, error) {
+	hrp, b, evm, err := DecodeAddress(addr)
+	if err != nil {
+		return "", err
+	}
+	algo := types.KeyAlgoSecp256k1
+	if hrp != "" {
+		algo = keyAlgoForPrefix(hrp)
+	} else if evm {
+		algo = types.KeyAlgoEthSecp256k1
+	}
+	return algo + ":" + hex.EncodeToString(b), nil
+}
+
+// AddressTimeout bounds the search of a single address in a batch, see SearchBatch. Zero or less means
+// no limit.
+var AddressTimeout time.Duration
+
+// SearchBatch searches every entry on every chain in the chain-registry and hands the results of each
+// entry to emit as soon as they are ready, in input order, including entries whose address cannot be
+// decoded. Entries that resolve to the same key are searched once, at their first occurrence, and RPC
+// clients come from the shared pool. Each address is given AddressTimeout, so a long batch is not cut
+// short by a single deadline. When ctx ends the entries not searched yet are still emitted, without
+// results and with the reason as error, and the context error is returned.
+func SearchBatch(ctx context.Context, entries []BatchEntry, emit func(BatchResult)) error {
+	if len(entries) == 0 {
+		return errors.New("no addresses to search")
+	}
+
+	type outcome struct {
+		results []ChainResult
+		errStr  string
+	}
+	searched := make(map[string]outcome)
+	for _, entry := range entries {
+		key, err := batchKey(entry.Address)
+		if err != nil {
+			emit(BatchResult{BatchEntry: entry, Results: make([]ChainResult, 0), Error: err.Error()})
+			continue
+		}
+		found, ok := searched[key]
+		if !ok && ctx.Err() != nil {
+			reason := "canceled"
+			if ctx.Err() == context.DeadlineExceeded {
+				reason = "timed out"
+			}
+			emit(BatchResult{BatchEntry: entry, Results: make([]ChainResult, 0), Error: reason})
+			continue
+		}
+		if !ok {
+			found = outcome{errStr: "ok"}
+			found.results, err = searchEntry(ctx, entry.Address)
+			if err != nil {
+				found.errStr = err.Error()
+			}
+			searched[key] = found
+		}
+		emit(BatchResult{BatchEntry: entry, Results: found.results, Error: found.errStr})
+	}
+	return ctx.Err()
+}
+
+// searchEntry searches a single address of a batch within AddressTimeout
+func searchEntry(ctx context.Context, account string) ([]ChainResult, error) {
+	if AddressTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, AddressTimeout)
+		defer cancel()
+	}
+	return searchAddress(ctx, account)
+}
+
+// searchAddress searches a single address on every chain in the chain-registry
+func searchAddress(ctx context.Context, account string) ([]ChainResult, error) {
+	derivations, err := DeriveAccounts(account, nil)
+	if err != nil {
+		return make([]ChainResult, 0), err
+	}
+	jobs := make([]searchJob, 0, len(derivations))
+	for chain, d := range derivations {
+		jobs = append(jobs, searchJob{chain: chain, addr: d.Address, keyAlgo: d.KeyAlgo, mismatch: d.Mismatch})
+	}
+	return withInterchainAccounts(ctx, fanOut(ctx, jobs)), nil
+}
@@ -0,0 +1,122 @@
+package findaccount
+
+import (
+	"fmt"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	"github.com/johnsaigle/findaccount/pkg/chaininfo"
+	"github.com/johnsaigle/findaccount/types"
+)
+
+// Coin is a balance held on a chain, expressed both in the on-chain base denom and in the display
+// units described by the chain-registry assetlist.
+type Coin struct {
+	Denom         string `json:"denom"`
+	Amount        string `json:"amount"`
+	DisplayDenom  string `json:"display_denom"`
+	DisplayAmount string `json:"display_amount"`
+	// IbcPath and BaseDenom are only set for ibc/ denoms, e.g. transfer/channel-0 and uosmo
+	IbcPath   string `json:"ibc_path,omitempty"`
+	BaseDenom string `json:"base_denom,omitempty"`
+}
+
+// String renders the coin in human units, e.g. "37,256.755969 ATOM"
+func (c Coin) String() string {
+	return fmt.Sprintf("%s %s", groupThousands(c.DisplayAmount), c.DisplayDenom)
+}
+
+// Coins is the list of balances held by an account on a single chain
+type Coins []Coin
+
+func (cs Coins) String() string {
+	s := make([]string, len(cs))
+	for i, c := range cs {
+		s[i] = c.String()
+	}
+	return strings.Join(s, "; ")
+}
+
+// toCoins converts the raw balances for a chain into Coins, using the assetlist for the chain when one
+// is available. Denoms without metadata are reported as-is. IBC denoms are annotated with their origin
+// from traces, or from the assetlist traces when the chain could not resolve them.
+func toCoins(chain string, coins sdk.Coins, traces map[string]transfertypes.DenomTrace) Coins {
+	result := make(Coins, 0, len(coins))
+	for _, c := range coins {
+		coin := toCoin(chain, c)
+		if trace, found := traces[c.Denom]; found {
+			coin.IbcPath = trace.Path
+			coin.BaseDenom = trace.BaseDenom
+		} else if asset, ok := chaininfo.LookupAsset(chain, c.Denom); ok {
+			coin.IbcPath, coin.BaseDenom = assetIbcOrigin(asset)
+		}
+		result = append(result, coin)
+	}
+	return result
+}
+
+// toCoin converts a single raw coin into display units using the assetlist for the chain
+func toCoin(chain string, c sdk.Coin) Coin {
+	coin := Coin{
+		Denom:         c.Denom,
+		Amount:        c.Amount.String(),
+		DisplayDenom:  c.Denom,
+		DisplayAmount: c.Amount.String(),
+	}
+	if asset, ok := chaininfo.LookupAsset(chain, c.Denom); ok {
+		coin.DisplayAmount = shiftDecimal(coin.Amount, asset.DisplayExponent())
+		coin.DisplayDenom = asset.DisplaySymbol()
+	}
+	return coin
+}
+
+// assetIbcOrigin extracts the transfer path and base denom from the ibc trace in the assetlist, if any
+func assetIbcOrigin(asset *types.Asset) (path, baseDenom string) {
+	for _, trace := range asset.Traces {
+		if trace.Type != "ibc" {
+			continue
+		}
+		baseDenom = trace.Counterparty.BaseDenom
+		path = strings.TrimSuffix(trace.Chain.Path, "/"+baseDenom)
+		if path == "" || path == trace.Chain.Path {
+			path = "transfer/" + trace.Chain.ChannelId
+		}
+		return
+	}
+	return
+}
+
+// shiftDecimal moves the decimal point of an integer string exp places to the left,
+// dropping any trailing zeros from the fractional part.
+func shiftDecimal(amount string, exp uint32) string {
+	if exp == 0 {
+		return amount
+	}
+	n := int(exp)
+	if len(amount) <= n {
+		amount = strings.Repeat("0", n-len(amount)+1) + amount
+	}
+	whole, frac := amount[:len(amount)-n], strings.TrimRight(amount[len(amount)-n:], "0")
+	if frac == "" {
+		return whole
+	}
+	return whole + "." + frac
+}
+
+// groupThousands inserts commas between every third digit of the whole part of a decimal string
+func groupThousands(amount string) string {
+	whole, frac, hasFrac := strings.Cut(amount, ".")
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	if hasFrac {
+		b.WriteByte('.')
+		b.WriteString(frac)
+	}
+	return b.String()
+}
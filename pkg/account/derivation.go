@@ -0,0 +1,116 @@
+package findaccount
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	cosmossecp256k1 "github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/johnsaigle/findaccount/types"
+	"golang.org/x/crypto/sha3"
+)
+
+// Derivation is the address of the searched key on a single chain
+type Derivation struct {
+	Address string
+	// Hex is the same address as a checksummed 0x address, for use with EVM tooling
+	Hex     string
+	KeyAlgo string
+	// Mismatch is set when the chain derives addresses with a different algorithm than the chain the
+	// input address came from and no public key was available. Address is then only a re-encoding of
+	// the input bytes and is not controlled by the same key.
+	Mismatch bool
+}
+
+// keyAlgoForPrefix returns the key algorithm of the chain using the bech32 prefix hrp. Prefixes that are
+// not in the chain-registry are assumed to be regular Cosmos chains.
+func keyAlgoForPrefix(hrp string) string {
+	for _, info := range infos {
+		if info.Bech32Prefix == hrp {
+			return info.KeyAlgo()
+		}
+	}
+	return types.KeyAlgoSecp256k1
+}
+
+// PubKeyAddress derives the 20 address bytes for a secp256k1 public key, given in compressed or
+// uncompressed form, using the hashing scheme of algo.
+func PubKeyAddress(pubkey []byte, algo string) ([]byte, error) {
+	pk, err := secp256k1.ParsePubKey(pubkey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secp256k1 public key: %w", err)
+	}
+	switch algo {
+	case types.KeyAlgoEthSecp256k1:
+		// Ethereum style: last 20 bytes of the keccak256 hash of the uncompressed key without its 0x04 prefix
+		h := sha3.NewLegacyKeccak256()
+		h.Write(pk.SerializeUncompressed()[1:])
+		return h.Sum(nil)[12:], nil
+	case types.KeyAlgoSecp256k1:
+		return (&cosmossecp256k1.PubKey{Key: pk.SerializeCompressed()}).Address(), nil
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm %q", algo)
+	}
+}
+
+// DeriveAccounts works out the address of the searched key on every chain in the registry. s may be a
+// bech32, hex or 0x address. When pubkey is nil the bytes of s are re-encoded with every prefix, and
+// chains whose key algorithm differs from the one s was derived with are flagged as mismatched. When
+// pubkey is given, addresses are derived from it with the algorithm of each chain and s, if not empty,
+// must match one of them.
+func DeriveAccounts(s string, pubkey []byte) (map[string]Derivation, error) {
+	accounts := make(map[string]Derivation)
+
+	var inputAlgo string
+	var inputBytes []byte
+	if s != "" {
+		hrp, b, evm, err := DecodeAddress(s)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case hrp != "":
+			inputAlgo = keyAlgoForPrefix(hrp)
+		case evm:
+			inputAlgo = types.KeyAlgoEthSecp256k1
+		default:
+			inputAlgo = types.KeyAlgoSecp256k1
+		}
+		inputBytes = b
+	}
+
+	derived := make(map[string][]byte)
+	if pubkey != nil {
+		for _, algo := range []string{types.KeyAlgoSecp256k1, types.KeyAlgoEthSecp256k1} {
+			b, err := PubKeyAddress(pubkey, algo)
+			if err != nil {
+				return nil, err
+			}
+			derived[algo] = b
+		}
+		if inputBytes != nil && !bytes.Equal(derived[inputAlgo], inputBytes) {
+			return nil, errors.New("public key does not match address")
+		}
+	} else if inputBytes == nil {
+		return nil, errors.New("an address or a public key is required")
+	}
+
+	for name, chainInfo := range infos {
+		algo := chainInfo.KeyAlgo()
+		b, ok := derived[algo]
+		mismatch := false
+		if !ok {
+			b = inputBytes
+			mismatch = algo != inputAlgo
+		}
+		addr, err := bech32.ConvertAndEncode(chainInfo.Bech32Prefix, b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		accounts[name] = Derivation{Address: addr, Hex: EIP55Address(b), KeyAlgo: algo, Mismatch: mismatch}
+	}
+
+	return accounts, nil
+}
@@ -0,0 +1,118 @@
+package findaccount
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/johnsaigle/findaccount/types"
+)
+
+// Bech32 prefix suffixes used by the staking module for validator addresses
+const (
+	suffixValoper    = "valoper"
+	suffixValcons    = "valcons"
+	suffixValconspub = "valconspub"
+)
+
+// aminoEd25519Prefix is the amino type prefix in front of an ed25519 key in a legacy bech32 valconspub
+var aminoEd25519Prefix = []byte{0x16, 0x24, 0xde, 0x64, 0x20}
+
+// AddressFamily holds every form of an address on a single chain. Account and operator addresses share
+// the same bytes, but consensus addresses belong to a separate key, so only the forms derivable from the
+// input are filled in.
+type AddressFamily struct {
+	Account    string `json:"account,omitempty"`
+	Hex        string `json:"hex,omitempty"`
+	Valoper    string `json:"valoper,omitempty"`
+	Valcons    string `json:"valcons,omitempty"`
+	Valconspub string `json:"valconspub,omitempty"`
+	// Mismatch has the same meaning as in Derivation
+	Mismatch bool `json:"mismatch"`
+}
+
+// splitPrefix works out which chain prefix and which address kind a bech32 hrp belongs to by matching
+// it against the prefixes in the chain-registry, e.g. cosmosvaloper is ("cosmos", "valoper").
+func splitPrefix(hrp string) (prefix, suffix string) {
+	for _, s := range []string{suffixValconspub, suffixValcons, suffixValoper} {
+		p := strings.TrimSuffix(hrp, s)
+		if p == hrp {
+			continue
+		}
+		for _, info := range infos {
+			if info.Bech32Prefix == p {
+				return p, s
+			}
+		}
+	}
+	return hrp, ""
+}
+
+// ConvertToAddressFamilies accepts an account, operator (valoper), consensus (valcons) or consensus
+// pubkey (valconspub) address, or a hex/0x account address, and re-encodes it for every chain in the
+// chain-registry in all the forms that can be derived from it.
+func ConvertToAddressFamilies(s string) (map[string]AddressFamily, error) {
+	families := make(map[string]AddressFamily)
+
+	hrp, b, evm, err := DecodeAddress(s)
+	if err != nil {
+		return nil, err
+	}
+	prefix, suffix := splitPrefix(hrp)
+
+	switch suffix {
+	case suffixValconspub:
+		if !bytes.HasPrefix(b, aminoEd25519Prefix) || len(b) != len(aminoEd25519Prefix)+32 {
+			return nil, fmt.Errorf("only ed25519 consensus keys are supported")
+		}
+		// consensus addresses are the first 20 bytes of the sha256 of the ed25519 key
+		sum := sha256.Sum256(b[len(aminoEd25519Prefix):])
+		consAddr := sum[:20]
+		for name, info := range infos {
+			valcons, e := bech32.ConvertAndEncode(info.Bech32Prefix+suffixValcons, consAddr)
+			if e != nil {
+				return nil, fmt.Errorf("%s: %w", name, e)
+			}
+			valconspub, e := bech32.ConvertAndEncode(info.Bech32Prefix+suffixValconspub, b)
+			if e != nil {
+				return nil, fmt.Errorf("%s: %w", name, e)
+			}
+			families[name] = AddressFamily{Valcons: valcons, Valconspub: valconspub}
+		}
+	case suffixValcons:
+		for name, info := range infos {
+			valcons, e := bech32.ConvertAndEncode(info.Bech32Prefix+suffixValcons, b)
+			if e != nil {
+				return nil, fmt.Errorf("%s: %w", name, e)
+			}
+			families[name] = AddressFamily{Valcons: valcons}
+		}
+	default:
+		inputAlgo := types.KeyAlgoSecp256k1
+		if hrp != "" {
+			inputAlgo = keyAlgoForPrefix(prefix)
+		} else if evm {
+			inputAlgo = types.KeyAlgoEthSecp256k1
+		}
+		for name, info := range infos {
+			acc, e := bech32.ConvertAndEncode(info.Bech32Prefix, b)
+			if e != nil {
+				return nil, fmt.Errorf("%s: %w", name, e)
+			}
+			valoper, e := bech32.ConvertAndEncode(info.Bech32Prefix+suffixValoper, b)
+			if e != nil {
+				return nil, fmt.Errorf("%s: %w", name, e)
+			}
+			families[name] = AddressFamily{
+				Account:  acc,
+				Hex:      EIP55Address(b),
+				Valoper:  valoper,
+				Mismatch: info.KeyAlgo() != inputAlgo,
+			}
+		}
+	}
+
+	return families, nil
+}
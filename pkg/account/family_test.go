@@ -0,0 +1,111 @@
+package findaccount
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/johnsaigle/findaccount/pkg/chaininfo"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+)
+
+func encode(t *testing.T, hrp string, b []byte) string {
+	t.Helper()
+	s, err := bech32.ConvertAndEncode(hrp, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestConvertToAddressFamilies(t *testing.T) {
+	if err := chaininfo.Load(testRegistry); err != nil {
+		t.Fatal(err)
+	}
+	_, acc, _, err := DecodeAddress(testAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, eth, _, err := DecodeAddress(ethAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consKey := ed25519.PubKey(bytes.Repeat([]byte{0x42}, 32))
+	aminoKey := append(append([]byte{}, aminoEd25519Prefix...), consKey...)
+	consAddr := consKey.Address()
+
+	accountFamily := map[string]AddressFamily{
+		"cosmoshub": {Account: testAddress, Hex: EIP55Address(acc), Valoper: testValoper},
+		"evmos": {
+			Account:  encode(t, "evmos", acc),
+			Hex:      EIP55Address(acc),
+			Valoper:  encode(t, "evmosvaloper", acc),
+			Mismatch: true,
+		},
+	}
+	consFamily := map[string]AddressFamily{
+		"cosmoshub": {Valcons: encode(t, "cosmosvalcons", consAddr), Valconspub: encode(t, "cosmosvalconspub", aminoKey)},
+		"evmos":     {Valcons: encode(t, "evmosvalcons", consAddr), Valconspub: encode(t, "evmosvalconspub", aminoKey)},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]AddressFamily
+		err   bool
+	}{
+		{name: "account", input: testAddress, want: accountFamily},
+		{name: "valoper", input: testValoper, want: accountFamily},
+		{name: "bare hex", input: "ee6e74038570ebc3e59acb7e8481f11e09a6516e", want: accountFamily},
+		{
+			name:  "0x address",
+			input: ethAddress,
+			want: map[string]AddressFamily{
+				"cosmoshub": {Account: encode(t, "cosmos", eth), Hex: ethAddress, Valoper: encode(t, "cosmosvaloper", eth), Mismatch: true},
+				"evmos":     {Account: encode(t, "evmos", eth), Hex: ethAddress, Valoper: encode(t, "evmosvaloper", eth)},
+			},
+		},
+		{
+			name:  "evmos account",
+			input: encode(t, "evmos", eth),
+			want: map[string]AddressFamily{
+				"cosmoshub": {Account: encode(t, "cosmos", eth), Hex: ethAddress, Valoper: encode(t, "cosmosvaloper", eth), Mismatch: true},
+				"evmos":     {Account: encode(t, "evmos", eth), Hex: ethAddress, Valoper: encode(t, "evmosvaloper", eth)},
+			},
+		},
+		{
+			// the consensus key cannot be recovered from its address
+			name:  "valcons",
+			input: encode(t, "cosmosvalcons", consAddr),
+			want: map[string]AddressFamily{
+				"cosmoshub": {Valcons: encode(t, "cosmosvalcons", consAddr)},
+				"evmos":     {Valcons: encode(t, "evmosvalcons", consAddr)},
+			},
+		},
+		{name: "valconspub", input: encode(t, "evmosvalconspub", aminoKey), want: consFamily},
+		{name: "valconspub without amino prefix", input: encode(t, "cosmosvalconspub", consKey), err: true},
+		{name: "invalid", input: "cosmos1notanaddress", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			families, err := ConvertToAddressFamilies(tt.input)
+			if tt.err {
+				if err == nil {
+					t.Errorf("accepted %q as %+v", tt.input, families)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(families) != len(tt.want) {
+				t.Errorf("got %d families, want %d", len(families), len(tt.want))
+			}
+			for chain, want := range tt.want {
+				if got := families[chain]; got != want {
+					t.Errorf("%s: got %+v, want %+v", chain, got, want)
+				}
+			}
+		})
+	}
+}
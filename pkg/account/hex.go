@@ -0,0 +1,68 @@
+package findaccount
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"golang.org/x/crypto/sha3"
+)
+
+// DecodeAddress accepts a bech32 address, a raw 20 byte hex address or an EIP-55 0x address and returns
+// the address bytes. hrp is empty for hex input; evm is set when the input used the 0x form, which
+// implies the key behind it derives addresses the Ethereum way.
+func DecodeAddress(s string) (hrp string, b []byte, evm bool, err error) {
+	s = strings.TrimSpace(s)
+	raw := s
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		raw, evm = s[2:], true
+	}
+	if len(raw) == 40 {
+		if b, e := hex.DecodeString(raw); e == nil {
+			if evm && raw != strings.ToLower(raw) && raw != strings.ToUpper(raw) && EIP55Address(b) != "0x"+raw {
+				return "", nil, false, errors.New("invalid EIP-55 checksum")
+			}
+			return "", b, evm, nil
+		}
+	}
+	if evm {
+		return "", nil, false, errors.New("0x addresses must be 20 bytes of hex")
+	}
+	hrp, b, err = bech32.DecodeAndConvert(s)
+	return hrp, b, false, err
+}
+
+// EIP55Address renders address bytes as a checksummed 0x address
+func EIP55Address(b []byte) string {
+	lower := hex.EncodeToString(b)
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(lower))
+	hash := h.Sum(nil)
+
+	out := []byte(lower)
+	for i, c := range out {
+		if c < 'a' {
+			continue
+		}
+		// each hex character is checked against the matching nibble of the hash
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if nibble >= 8 {
+			out[i] = c - 32
+		}
+	}
+	return "0x" + string(out)
+}
+
+// hexAddress converts a bech32 address to its 0x form, or returns an empty string if it cannot be decoded
+// or is not 20 bytes long, as with the 32 byte addresses of module and interchain accounts
+func hexAddress(addr string) string {
+	_, b, err := bech32.DecodeAndConvert(addr)
+	if err != nil || len(b) != 20 {
+		return ""
+	}
+	return EIP55Address(b)
+}
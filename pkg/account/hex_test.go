@@ -0,0 +1,69 @@
+package findaccount
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// ethAddress is the Ethereum address of private key 1
+const ethAddress = "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf"
+
+func TestDecodeAddress(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		hrp   string
+		hex   string
+		evm   bool
+		err   bool
+	}{
+		{name: "checksummed", input: ethAddress, hex: "7e5f4552091a69125d5dfcb7b8c2659029395bdf", evm: true},
+		{name: "all lowercase", input: strings.ToLower(ethAddress), hex: "7e5f4552091a69125d5dfcb7b8c2659029395bdf", evm: true},
+		{name: "all uppercase", input: "0x" + strings.ToUpper(ethAddress[2:]), hex: "7e5f4552091a69125d5dfcb7b8c2659029395bdf", evm: true},
+		{name: "bad checksum", input: "0x7e5F4552091A69125d5DfCb7b8C2659029395Bdf", err: true},
+		{name: "bare hex", input: "7e5f4552091a69125d5dfcb7b8c2659029395bdf", hex: "7e5f4552091a69125d5dfcb7b8c2659029395bdf"},
+		{name: "surrounding space", input: " " + ethAddress + "\n", hex: "7e5f4552091a69125d5dfcb7b8c2659029395bdf", evm: true},
+		{name: "0x too short", input: ethAddress[:40], err: true},
+		{name: "0x too long", input: ethAddress + "00", err: true},
+		{name: "bare hex too short", input: "7e5f4552091a69125d5dfcb7b8c2659029395b", err: true},
+		{name: "bech32", input: testAddress, hrp: "cosmos", hex: "ee6e74038570ebc3e59acb7e8481f11e09a6516e"},
+		{name: "invalid", input: "cosmos1notanaddress", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hrp, b, evm, err := DecodeAddress(tt.input)
+			if tt.err {
+				if err == nil {
+					t.Errorf("accepted %q as %x", tt.input, b)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hrp != tt.hrp || hex.EncodeToString(b) != tt.hex || evm != tt.evm {
+				t.Errorf("got (%q, %x, %v), want (%q, %s, %v)", hrp, b, evm, tt.hrp, tt.hex, tt.evm)
+			}
+		})
+	}
+}
+
+func TestEIP55Address(t *testing.T) {
+	// the last four are the examples of EIP-55
+	for _, want := range []string{
+		ethAddress,
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		b, err := hex.DecodeString(strings.ToLower(want[2:]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := EIP55Address(b); got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+}
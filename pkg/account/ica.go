@@ -0,0 +1,125 @@
+package findaccount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/johnsaigle/findaccount/pkg/client"
+)
+
+// DerivedViaICA marks results for interchain accounts owned by the searched address on another chain
+const DerivedViaICA = "ica"
+
+// icaQueryLimit caps the number of concurrent InterchainAccount queries against a single controller chain
+const icaQueryLimit = 8
+
+// InterchainAccount is an account on a host chain that the searched address controls over IBC
+type InterchainAccount struct {
+	ConnectionId string `json:"connection_id"`
+	// HostChain is the chain-registry name of the host chain, or the bech32 prefix of Address when the
+	// chain is not in the registry
+	HostChain string `json:"host_chain"`
+	Address   string `json:"address"`
+}
+
+// chainForPrefix returns the chain-registry name of the chain using the bech32 prefix hrp
+func chainForPrefix(hrp string) (string, bool) {
+	for name, info := range infos {
+		if info.Bech32Prefix == hrp {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// queryInterchainAccounts asks the chain, acting as an interchain accounts controller, for the accounts
+// owner has registered over each of its open connections. Chains with the controller disabled have none.
+func queryInterchainAccounts(ctx context.Context, q client.ChainQuerier, owner string) ([]InterchainAccount, error) {
+	enabled, err := q.ControllerEnabled(ctx)
+	if err != nil || !enabled {
+		return nil, err
+	}
+	connections, err := q.OpenConnections(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// every goroutine writes to its own slot so the accounts keep the order of the connections
+	found := make([]*InterchainAccount, len(connections))
+	errs := make([]error, len(connections))
+	sem := make(chan struct{}, icaQueryLimit)
+	wg := &sync.WaitGroup{}
+	for i, conn := range connections {
+		i, connectionId := i, conn.Id
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			addr, e := q.InterchainAccount(ctx, owner, connectionId)
+			if errors.Is(e, client.ErrNotFound) || (e == nil && addr == "") {
+				return
+			}
+			if e != nil {
+				errs[i] = e
+				return
+			}
+			ica := InterchainAccount{ConnectionId: connectionId, Address: addr}
+			if hrp, _, e := bech32.DecodeAndConvert(addr); e == nil {
+				ica.HostChain = hrp
+				if name, ok := chainForPrefix(hrp); ok {
+					ica.HostChain = name
+				}
+			}
+			found[i] = &ica
+		}()
+	}
+	wg.Wait()
+
+	var accounts []InterchainAccount
+	for _, ica := range found {
+		if ica != nil {
+			accounts = append(accounts, *ica)
+		}
+	}
+
+	return accounts, errors.Join(errs...)
+}
+
+// withInterchainAccounts searches the host chains of the interchain accounts found in results and adds
+// them to the results, marked as derived via ICA. Host chains missing from the chain-registry cannot be
+// queried and are reported with an error.
+func withInterchainAccounts(ctx context.Context, results []ChainResult) []ChainResult {
+	jobs := make([]searchJob, 0)
+	unknown := make([]ChainResult, 0)
+	for _, r := range results {
+		for _, ica := range r.InterchainAccounts {
+			controller := r.Chain + "/" + ica.ConnectionId
+			if _, ok := infos[ica.HostChain]; !ok {
+				unknown = append(unknown, ChainResult{
+					Chain:      ica.HostChain,
+					Address:    ica.Address,
+					DerivedVia: DerivedViaICA,
+					Controller: controller,
+					Activity:   make([]string, 0),
+					Error:      fmt.Sprintf("host chain of %s is not in the chain-registry", ica.ConnectionId),
+				})
+				continue
+			}
+			jobs = append(jobs, searchJob{
+				chain:      ica.HostChain,
+				addr:       ica.Address,
+				keyAlgo:    infos[ica.HostChain].KeyAlgo(),
+				derivedVia: DerivedViaICA,
+				controller: controller,
+			})
+		}
+	}
+	if len(jobs) == 0 && len(unknown) == 0 {
+		return results
+	}
+	results = append(results, fanOut(ctx, jobs)...)
+	return append(results, unknown...)
+}
@@ -0,0 +1,92 @@
+package findaccount
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/johnsaigle/findaccount/types"
+)
+
+// keyAlgos are the address derivations tried for every chain when searching by public key
+var keyAlgos = []string{types.KeyAlgoSecp256k1, types.KeyAlgoEthSecp256k1}
+
+// ParsePubKey decodes a secp256k1 public key given as hex (with or without 0x), base64, or the Any JSON
+// found in transactions, e.g. {"@type":"/cosmos.crypto.secp256k1.PubKey","key":"A..."}. Both the
+// Cosmos and the Ethermint key types are accepted since they share the same curve.
+func ParsePubKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "{") {
+		var packed struct {
+			Type string `json:"@type"`
+			Key  string `json:"key"`
+		}
+		err := json.Unmarshal([]byte(s), &packed)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode public key JSON: %w", err)
+		}
+		if !strings.HasSuffix(packed.Type, "secp256k1.PubKey") {
+			return nil, fmt.Errorf("unsupported public key type %q", packed.Type)
+		}
+		s = packed.Key
+	}
+
+	if b, err := hex.DecodeString(strings.TrimPrefix(s, "0x")); err == nil && validPubKeyLength(b) {
+		return b, nil
+	}
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil && validPubKeyLength(b) {
+		return b, nil
+	}
+	return nil, errors.New("public key must be a 33 or 65 byte secp256k1 key in hex, base64 or Any JSON form")
+}
+
+// validPubKeyLength accepts compressed and uncompressed secp256k1 keys
+func validPubKeyLength(b []byte) bool {
+	return len(b) == 33 || len(b) == 65
+}
+
+// SearchPubKey searches every chain for the addresses controlled by pubkey. Both the Cosmos and the
+// Ethermint derivation are tried on every chain, since chains that migrated between the two can hold
+// funds under either address. For each chain the derivations that show activity are returned; when
+// neither does, only the chain's own derivation is kept.
+func SearchPubKey(ctx context.Context, pubkey []byte) ([]ChainResult, error) {
+	derived := make(map[string][]byte)
+	for _, algo := range keyAlgos {
+		b, err := PubKeyAddress(pubkey, algo)
+		if err != nil {
+			return nil, err
+		}
+		derived[algo] = b
+	}
+
+	jobs := make([]searchJob, 0, len(infos)*len(keyAlgos))
+	for chain, info := range infos {
+		for _, algo := range keyAlgos {
+			addr, err := bech32.ConvertAndEncode(info.Bech32Prefix, derived[algo])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", chain, err)
+			}
+			jobs = append(jobs, searchJob{chain: chain, addr: addr, keyAlgo: algo})
+		}
+	}
+
+	all := fanOut(ctx, jobs)
+	found := make(map[string]bool)
+	for _, r := range all {
+		if r.Exists {
+			found[r.Chain] = true
+		}
+	}
+	results := make([]ChainResult, 0, len(infos))
+	for _, r := range all {
+		if r.Exists || (!found[r.Chain] && r.Derivation == infos[r.Chain].KeyAlgo()) {
+			results = append(results, r)
+		}
+	}
+	return withInterchainAccounts(ctx, results), nil
+}
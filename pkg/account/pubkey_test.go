@@ -0,0 +1,95 @@
+package findaccount
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/johnsaigle/findaccount/types"
+)
+
+// The public key of private key 1, the generator point of secp256k1
+const (
+	compressedG   = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	uncompressedG = "0479be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"
+)
+
+func TestParsePubKey(t *testing.T) {
+	compressed, _ := hex.DecodeString(compressedG)
+	uncompressed, _ := hex.DecodeString(uncompressedG)
+	b64 := base64.StdEncoding.EncodeToString(compressed)
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		err   bool
+	}{
+		{name: "hex", input: compressedG, want: compressedG},
+		{name: "uppercase 0x hex", input: "0x" + strings.ToUpper(compressedG), want: compressedG},
+		{name: "uncompressed hex", input: uncompressedG, want: uncompressedG},
+		{name: "base64", input: b64, want: compressedG},
+		{name: "uncompressed base64", input: base64.StdEncoding.EncodeToString(uncompressed), want: uncompressedG},
+		{name: "cosmos Any", input: `{"@type":"/cosmos.crypto.secp256k1.PubKey","key":"` + b64 + `"}`, want: compressedG},
+		{name: "ethermint Any", input: `{"@type":"/ethermint.crypto.v1.ethsecp256k1.PubKey","key":"` + b64 + `"}`, want: compressedG},
+		{name: "ed25519 Any", input: `{"@type":"/cosmos.crypto.ed25519.PubKey","key":"` + b64 + `"}`, err: true},
+		{name: "Any without type", input: `{"key":"` + b64 + `"}`, err: true},
+		{name: "invalid JSON", input: `{"@type":`, err: true},
+		{name: "short hex", input: compressedG[:64], err: true},
+		{name: "64 byte base64", input: base64.StdEncoding.EncodeToString(uncompressed[1:]), err: true},
+		{name: "garbage", input: "not a key", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := ParsePubKey(tt.input)
+			if tt.err {
+				if err == nil {
+					t.Errorf("accepted %q as %x", tt.input, b)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex.EncodeToString(b) != tt.want {
+				t.Errorf("got %x, want %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestPubKeyAddress(t *testing.T) {
+	tests := []struct {
+		name   string
+		pubkey string
+		algo   string
+		want   string
+		err    bool
+	}{
+		{name: "secp256k1", pubkey: compressedG, algo: types.KeyAlgoSecp256k1, want: "751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{name: "secp256k1 from uncompressed", pubkey: uncompressedG, algo: types.KeyAlgoSecp256k1, want: "751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{name: "ethsecp256k1", pubkey: compressedG, algo: types.KeyAlgoEthSecp256k1, want: strings.ToLower(ethAddress[2:])},
+		{name: "ethsecp256k1 from uncompressed", pubkey: uncompressedG, algo: types.KeyAlgoEthSecp256k1, want: strings.ToLower(ethAddress[2:])},
+		{name: "unsupported algorithm", pubkey: compressedG, algo: "ed25519", err: true},
+		{name: "not on the curve", pubkey: "02" + strings.Repeat("00", 32), algo: types.KeyAlgoSecp256k1, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pubkey, _ := hex.DecodeString(tt.pubkey)
+			b, err := PubKeyAddress(pubkey, tt.algo)
+			if tt.err {
+				if err == nil {
+					t.Errorf("derived %x", b)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex.EncodeToString(b) != tt.want {
+				t.Errorf("got %x, want %s", b, tt.want)
+			}
+		})
+	}
+}
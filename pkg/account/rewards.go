@@ -0,0 +1,95 @@
+package findaccount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/johnsaigle/findaccount/pkg/client"
+)
+
+// Rewards holds the staking rewards an account can withdraw on a chain. Commission and SelfDelegation
+// are only set when the account controls a validator.
+type Rewards struct {
+	Total          Coins             `json:"total"`
+	ByValidator    []ValidatorReward `json:"by_validator"`
+	Commission     Coins             `json:"commission,omitempty"`
+	SelfDelegation *Coin             `json:"self_delegation,omitempty"`
+}
+
+type ValidatorReward struct {
+	Validator string `json:"validator"`
+	Reward    Coins  `json:"reward"`
+}
+
+func (r Rewards) String() string {
+	return r.Total.String()
+}
+
+func (r Rewards) CommissionString() string {
+	parts := make([]string, 0, 2)
+	if len(r.Commission) > 0 {
+		parts = append(parts, r.Commission.String())
+	}
+	if r.SelfDelegation != nil {
+		parts = append(parts, fmt.Sprintf("self-delegation: %s", r.SelfDelegation))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// decToCoins truncates reward amounts to whole base units; anything smaller cannot be withdrawn anyway
+func decToCoins(chain string, decCoins sdk.DecCoins) Coins {
+	result := make(Coins, 0, len(decCoins))
+	for _, c := range decCoins {
+		amount := c.Amount.TruncateInt()
+		if amount.IsZero() {
+			continue
+		}
+		result = append(result, toCoin(chain, sdk.Coin{Denom: c.Denom, Amount: amount}))
+	}
+	return result
+}
+
+// queryRewards collects the outstanding delegation rewards of addr on chain, and the accumulated
+// commission and self-delegation of its validator when isValidator is set.
+func queryRewards(ctx context.Context, q client.ChainQuerier, chain, addr, prefix string, isValidator bool) (rewards Rewards, err error) {
+	byValidator, total, err := q.DelegationRewards(ctx, addr)
+	if err != nil {
+		return
+	}
+	rewards.Total = decToCoins(chain, total)
+	for _, r := range byValidator {
+		rewards.ByValidator = append(rewards.ByValidator, ValidatorReward{
+			Validator: r.ValidatorAddress,
+			Reward:    decToCoins(chain, r.Reward),
+		})
+	}
+	if !isValidator {
+		return
+	}
+
+	valoper, err := client.ValoperAddress(addr, prefix)
+	if err != nil {
+		return
+	}
+	commission, err := q.ValidatorCommission(ctx, valoper)
+	if err != nil {
+		return
+	}
+	rewards.Commission = decToCoins(chain, commission)
+	self, err := q.Delegation(ctx, addr, valoper)
+	// a validator whose operator withdrew its whole stake has no self-delegation
+	if errors.Is(err, client.ErrNotFound) {
+		return rewards, nil
+	}
+	if err != nil {
+		return
+	}
+	if self.Balance.Denom != "" {
+		selfDelegation := toCoin(chain, self.Balance)
+		rewards.SelfDelegation = &selfDelegation
+	}
+	return
+}
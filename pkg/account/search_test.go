@@ -0,0 +1,241 @@
+package findaccount
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/johnsaigle/findaccount/pkg/chaininfo"
+	"github.com/johnsaigle/findaccount/pkg/client"
+	"github.com/johnsaigle/findaccount/pkg/mockrpc"
+	"github.com/johnsaigle/findaccount/types"
+)
+
+// hubRow is the cosmoshub result for testAddress on mockrpc.DefaultFixture
+const hubRow = `cosmoshub,cosmos1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twlpvf6m,0xeE6e74038570EBC3e59aCb7e8481F11E09A6516e,secp256k1,,,true,"account; signed; balance; staked; rewards; validator","Example (bonded, #2, missed 3/100)","base #1234 seq 12",true,"2.5 OSMO; 37,256.755969 ATOM","Example: 5,000 ATOM; Other: 1 ATOM","Other: 0.5 ATOM until 2030-01-01T00:00:00Z","","15.75 ATOM","0.001234 ATOM; self-delegation: 5,000 ATOM",false,rpc,ok`
+
+// startNetwork serves fixture, or mockrpc.DefaultFixture when it is nil, and points searches at it
+func startNetwork(t *testing.T, fixture *mockrpc.Fixture) *mockrpc.Network {
+	t.Helper()
+	var network *mockrpc.Network
+	var err error
+	if fixture == nil {
+		network, err = mockrpc.StartDefault()
+	} else {
+		network, err = mockrpc.Start(fixture)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(network.Close)
+	err = chaininfo.Load(network.Registry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HealthFile = ""
+	// pooled clients would still point at the servers of an earlier test
+	SetPool(client.NewPool(client.DefaultRevalidateInterval))
+	return network
+}
+
+// rows returns the CSV rows of results by chain and derivation, e.g. "osmosis/ica"
+func rows(results []ChainResult) map[string]ChainResult {
+	byChain := make(map[string]ChainResult, len(results))
+	for _, r := range results {
+		key := r.Chain
+		if r.DerivedVia != "" {
+			key += "/" + r.DerivedVia
+		}
+		byChain[key] = r
+	}
+	return byChain
+}
+
+func TestSearchAccountsOnMockNetwork(t *testing.T) {
+	startNetwork(t, nil)
+
+	results, err := SearchAccounts(context.Background(), testAddress, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want cosmoshub, osmosis and the interchain account on osmosis", len(results))
+	}
+	found := rows(results)
+
+	if row := found["cosmoshub"].ToCsv(); row != hubRow {
+		t.Errorf("got cosmoshub row\n%s\nwant\n%s", row, hubRow)
+	}
+	hub := found["cosmoshub"]
+	if hub.Validator == nil || hub.Validator.Tokens.String() != "5,000 ATOM" || hub.Validator.Commission.Rate == "" {
+		t.Errorf("got validator %+v", hub.Validator)
+	}
+	if len(hub.InterchainAccounts) != 1 || hub.InterchainAccounts[0].Address != "osmo1fx6893deednhgupzp5p5j7awexcync08qkn5j6ndxq66sdltfzlq2pns9k" {
+		t.Errorf("got interchain accounts %+v", hub.InterchainAccounts)
+	}
+
+	osmosis := found["osmosis"]
+	if osmosis.Address != "osmo1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twh6levf" || !osmosis.Exists || osmosis.ActivityString() != "account; balance" {
+		t.Errorf("got osmosis row %s", osmosis.ToCsv())
+	}
+	if osmosis.Coins.String() != "119,849.309021 OSMO" || osmosis.Validator != nil {
+		t.Errorf("got osmosis row %s", osmosis.ToCsv())
+	}
+
+	ica := found["osmosis/ica"]
+	if ica.Controller != "cosmoshub/connection-0" || ica.Coins.String() != "5 OSMO" || ica.ActivityString() != "account; signed; balance" || ica.Error != "ok" {
+		t.Errorf("got interchain account row %s", ica.ToCsv())
+	}
+}
+
+func TestSearchAccountsTimesOutOnMockNetwork(t *testing.T) {
+	startNetwork(t, nil)
+	saved := ChainTimeout
+	t.Cleanup(func() { ChainTimeout = saved })
+	ChainTimeout = time.Nanosecond
+
+	results, err := SearchAccounts(context.Background(), testAddress, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want one per chain", len(results))
+	}
+	for _, r := range results {
+		if !r.TimedOut || r.Error != "timed out" || r.Exists {
+			t.Errorf("got %s row %s, want it timed out with nothing found", r.Chain, r.ToCsv())
+		}
+	}
+}
+
+func TestSearchPubKeyOnMockNetwork(t *testing.T) {
+	pubkey := secp256k1.GenPrivKeyFromSecret([]byte("findaccount")).PubKey().Bytes()
+	cosmosAddr, err := PubKeyAddress(pubkey, types.KeyAlgoSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ethAddr, err := PubKeyAddress(pubkey, types.KeyAlgoEthSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hubAddr, _ := bech32.ConvertAndEncode("cosmos", cosmosAddr)
+	osmoAddr, _ := bech32.ConvertAndEncode("osmo", ethAddr)
+
+	// the key holds funds under the Cosmos address on cosmoshub and under the Ethermint address on osmosis
+	fixture, err := mockrpc.ParseFixture(bytes.NewReader(mockrpc.DefaultFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, chain := range fixture.Chains {
+		switch chain.Name {
+		case "cosmoshub":
+			fixture.Chains[i].Accounts = append(chain.Accounts, mockrpc.Account{Address: hubAddr, Coins: "2000000uatom", AccountNumber: 9})
+		case "osmosis":
+			fixture.Chains[i].Accounts = append(chain.Accounts, mockrpc.Account{Address: osmoAddr, Coins: "3000000uosmo", AccountNumber: 5, Sequence: 1})
+		}
+	}
+	startNetwork(t, fixture)
+
+	results, err := SearchPubKey(context.Background(), pubkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want one per chain", len(results))
+	}
+	found := rows(results)
+	hub, osmosis := found["cosmoshub"], found["osmosis"]
+	if hub.Address != hubAddr || hub.Derivation != types.KeyAlgoSecp256k1 || hub.Coins.String() != "2 ATOM" || hub.ActivityString() != "account; balance" {
+		t.Errorf("got cosmoshub row %s", hub.ToCsv())
+	}
+	if osmosis.Address != osmoAddr || osmosis.Derivation != types.KeyAlgoEthSecp256k1 || osmosis.Coins.String() != "3 OSMO" || osmosis.ActivityString() != "account; signed; balance" {
+		t.Errorf("got osmosis row %s", osmosis.ToCsv())
+	}
+}
+
+func TestSearchBatchOnMockNetwork(t *testing.T) {
+	startNetwork(t, nil)
+
+	entries, err := ReadBatch(strings.NewReader("label,address\ntreasury," + testAddress + "\nbroken,cosmos1notanaddress\nsame key,osmo1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twh6levf\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var batch []BatchResult
+	err = SearchBatch(context.Background(), entries, func(r BatchResult) {
+		batch = append(batch, r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(batch) != 3 {
+		t.Fatalf("got %d batch results, want one per entry", len(batch))
+	}
+	for i, label := range []string{"treasury", "broken", "same key"} {
+		if batch[i].Label != label {
+			t.Errorf("result %d is labelled %q, want %q", i, batch[i].Label, label)
+		}
+	}
+	if batch[0].Error != "ok" || rows(batch[0].Results)["cosmoshub"].ToCsv() != hubRow {
+		t.Errorf("got treasury results %+v", batch[0])
+	}
+	if batch[1].Error == "ok" || len(batch[1].Results) != 0 {
+		t.Errorf("got results for an invalid address: %+v", batch[1])
+	}
+	// both addresses share a key, so they share the results
+	if batch[2].Error != "ok" || len(batch[2].Results) != len(batch[0].Results) || rows(batch[2].Results)["cosmoshub"].ToCsv() != hubRow {
+		t.Errorf("got same key results %+v", batch[2])
+	}
+}
+
+func TestSearchBatchDeadlines(t *testing.T) {
+	startNetwork(t, nil)
+	entries := []BatchEntry{
+		{Label: "hub", Address: testAddress},
+		{Label: "other", Address: "cosmos1kzvsfy5p75tm7p7sdnsvt7lmugmputuqzgytgf"},
+	}
+
+	// every address gets its own deadline, so all of them are searched and time out on their own
+	saved := AddressTimeout
+	t.Cleanup(func() { AddressTimeout = saved })
+	AddressTimeout = time.Nanosecond
+	var batch []BatchResult
+	err := SearchBatch(context.Background(), entries, func(r BatchResult) {
+		batch = append(batch, r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch) != 2 {
+		t.Fatalf("got %d batch results, want one per entry", len(batch))
+	}
+	for _, b := range batch {
+		if b.Error != "ok" || len(b.Results) != 2 {
+			t.Fatalf("got %s results %+v, want one per chain", b.Label, b)
+		}
+		for _, r := range b.Results {
+			if !r.TimedOut || r.Error != "timed out" {
+				t.Errorf("got %s row %s, want it timed out", b.Label, r.ToCsv())
+			}
+		}
+	}
+
+	// entries left when the batch is canceled are still reported
+	AddressTimeout = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	batch = nil
+	err = SearchBatch(ctx, entries, func(r BatchResult) {
+		batch = append(batch, r)
+	})
+	if err != context.Canceled {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if len(batch) != 2 || batch[0].Error != "canceled" || batch[1].Error != "canceled" {
+		t.Errorf("got %+v, want every entry reported as canceled", batch)
+	}
+}
@@ -0,0 +1,139 @@
+package findaccount
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/johnsaigle/findaccount/pkg/client"
+)
+
+// Staking holds the value an account has bonded, or is in the process of unbonding, on a chain
+type Staking struct {
+	Delegations   []Delegation   `json:"delegations"`
+	Unbonding     []Unbonding    `json:"unbonding"`
+	Redelegations []Redelegation `json:"redelegations"`
+}
+
+type Delegation struct {
+	Validator string `json:"validator"`
+	Moniker   string `json:"moniker"`
+	Balance   Coin   `json:"balance"`
+}
+
+type Unbonding struct {
+	Validator      string    `json:"validator"`
+	Moniker        string    `json:"moniker"`
+	Balance        Coin      `json:"balance"`
+	CompletionTime time.Time `json:"completion_time"`
+}
+
+type Redelegation struct {
+	SourceValidator      string    `json:"source_validator"`
+	SourceMoniker        string    `json:"source_moniker"`
+	DestinationValidator string    `json:"destination_validator"`
+	DestinationMoniker   string    `json:"destination_moniker"`
+	Balance              Coin      `json:"balance"`
+	CompletionTime       time.Time `json:"completion_time"`
+}
+
+// IsEmpty reports whether the account has nothing bonded, unbonding or redelegating
+func (s Staking) IsEmpty() bool {
+	return len(s.Delegations) == 0 && len(s.Unbonding) == 0 && len(s.Redelegations) == 0
+}
+
+func (s Staking) DelegationsString() string {
+	out := make([]string, len(s.Delegations))
+	for i, d := range s.Delegations {
+		out[i] = fmt.Sprintf("%s: %s", d.Moniker, d.Balance)
+	}
+	return strings.Join(out, "; ")
+}
+
+func (s Staking) UnbondingString() string {
+	out := make([]string, len(s.Unbonding))
+	for i, u := range s.Unbonding {
+		out[i] = fmt.Sprintf("%s: %s until %s", u.Moniker, u.Balance, u.CompletionTime.Format(time.RFC3339))
+	}
+	return strings.Join(out, "; ")
+}
+
+func (s Staking) RedelegationsString() string {
+	out := make([]string, len(s.Redelegations))
+	for i, r := range s.Redelegations {
+		out[i] = fmt.Sprintf("%s -> %s: %s until %s", r.SourceMoniker, r.DestinationMoniker, r.Balance, r.CompletionTime.Format(time.RFC3339))
+	}
+	return strings.Join(out, "; ")
+}
+
+// queryStaking collects the delegations, unbonding delegations and redelegations of addr on chain.
+// Whatever could be retrieved is returned alongside the first error encountered.
+func queryStaking(ctx context.Context, q client.ChainQuerier, chain, addr string) (staking Staking, err error) {
+	monikers := make(map[string]string)
+	moniker := func(valoper string) string {
+		if m, ok := monikers[valoper]; ok {
+			return m
+		}
+		val, e := q.Validator(ctx, valoper)
+		if e != nil {
+			return ""
+		}
+		monikers[valoper] = val.GetMoniker()
+		return monikers[valoper]
+	}
+
+	delegations, err := q.Delegations(ctx, addr)
+	if err != nil {
+		return
+	}
+	for _, d := range delegations {
+		staking.Delegations = append(staking.Delegations, Delegation{
+			Validator: d.Delegation.ValidatorAddress,
+			Moniker:   moniker(d.Delegation.ValidatorAddress),
+			Balance:   toCoin(chain, d.Balance),
+		})
+	}
+
+	unbonding, err := q.UnbondingDelegations(ctx, addr)
+	if err != nil {
+		return
+	}
+	redelegations, err := q.Redelegations(ctx, addr)
+	if err != nil {
+		return
+	}
+	if len(unbonding) == 0 && len(redelegations) == 0 {
+		return
+	}
+
+	// unbonding and redelegation entries only carry an amount, not a denom
+	bondDenom, err := q.BondDenom(ctx)
+	if err != nil {
+		return
+	}
+	for _, u := range unbonding {
+		for _, entry := range u.Entries {
+			staking.Unbonding = append(staking.Unbonding, Unbonding{
+				Validator:      u.ValidatorAddress,
+				Moniker:        moniker(u.ValidatorAddress),
+				Balance:        toCoin(chain, sdk.Coin{Denom: bondDenom, Amount: entry.Balance}),
+				CompletionTime: entry.CompletionTime,
+			})
+		}
+	}
+	for _, r := range redelegations {
+		for _, entry := range r.Entries {
+			staking.Redelegations = append(staking.Redelegations, Redelegation{
+				SourceValidator:      r.Redelegation.ValidatorSrcAddress,
+				SourceMoniker:        moniker(r.Redelegation.ValidatorSrcAddress),
+				DestinationValidator: r.Redelegation.ValidatorDstAddress,
+				DestinationMoniker:   moniker(r.Redelegation.ValidatorDstAddress),
+				Balance:              toCoin(chain, sdk.Coin{Denom: bondDenom, Amount: entry.Balance}),
+				CompletionTime:       entry.RedelegationEntry.CompletionTime,
+			})
+		}
+	}
+	return
+}
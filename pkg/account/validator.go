@@ -0,0 +1,181 @@
+package findaccount
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	staketypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/johnsaigle/findaccount/pkg/client"
+)
+
+// Validator describes the operational state of a validator controlled by the searched account
+type Validator struct {
+	Moniker             string         `json:"moniker"`
+	OperatorAddress     string         `json:"operator_address"`
+	ConsensusAddress    string         `json:"consensus_address"`
+	ConsensusPubkey     string         `json:"consensus_pubkey"`
+	ConsensusPubkeyType string         `json:"consensus_pubkey_type"`
+	Status              string         `json:"status"`
+	Jailed              bool           `json:"jailed"`
+	Tokens              Coin           `json:"tokens"`
+	DelegatorShares     string         `json:"delegator_shares"`
+	Commission          CommissionRate `json:"commission"`
+	// Rank is the position by voting power in the active set, 0 when the validator is not bonded
+	Rank    int          `json:"rank"`
+	Signing *SigningInfo `json:"signing,omitempty"`
+}
+
+// SigningInfo reports the liveness of a validator as tracked by the slashing module
+type SigningInfo struct {
+	MissedBlocks       int64     `json:"missed_blocks"`
+	SignedBlocksWindow int64     `json:"signed_blocks_window"`
+	MinSignedPerWindow string    `json:"min_signed_per_window"`
+	JailedUntil        time.Time `json:"jailed_until"`
+	Tombstoned         bool      `json:"tombstoned"`
+}
+
+type CommissionRate struct {
+	Rate          string `json:"rate"`
+	MaxRate       string `json:"max_rate"`
+	MaxChangeRate string `json:"max_change_rate"`
+}
+
+// String summarises the validator, e.g. "Everstake (bonded, #12)"
+func (v *Validator) String() string {
+	if v == nil {
+		return ""
+	}
+	details := []string{v.Status}
+	if v.Jailed {
+		details = append(details, "jailed")
+	}
+	if v.Rank > 0 {
+		details = append(details, fmt.Sprintf("#%d", v.Rank))
+	}
+	if v.Signing != nil {
+		if v.Signing.Tombstoned {
+			details = append(details, "tombstoned")
+		}
+		details = append(details, fmt.Sprintf("missed %d/%d", v.Signing.MissedBlocks, v.Signing.SignedBlocksWindow))
+	}
+	return fmt.Sprintf("%s (%s)", v.Moniker, strings.Join(details, ", "))
+}
+
+// bondStatus maps the staking module enum onto the short names used in the output
+func bondStatus(status staketypes.BondStatus) string {
+	switch status {
+	case staketypes.Bonded:
+		return "bonded"
+	case staketypes.Unbonding:
+		return "unbonding"
+	case staketypes.Unbonded:
+		return "unbonded"
+	default:
+		return "unspecified"
+	}
+}
+
+// queryValidator builds the validator profile for the validator operated by addr, if there is one.
+// A nil Validator with a nil error means the account does not operate a validator.
+func queryValidator(ctx context.Context, q client.ChainQuerier, chain, addr, prefix string) (*Validator, error) {
+	valoper, err := client.ValoperAddress(addr, prefix)
+	if err != nil {
+		return nil, err
+	}
+	val, err := q.Validator(ctx, valoper)
+	if errors.Is(err, client.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	validator := &Validator{
+		Moniker:         val.GetMoniker(),
+		OperatorAddress: val.OperatorAddress,
+		Status:          bondStatus(val.Status),
+		Jailed:          val.Jailed,
+		DelegatorShares: val.DelegatorShares.String(),
+		Commission: CommissionRate{
+			Rate:          val.Commission.Rate.String(),
+			MaxRate:       val.Commission.MaxRate.String(),
+			MaxChangeRate: val.Commission.MaxChangeRate.String(),
+		},
+	}
+	// a failed lookup leaves its part of the profile empty without hiding the rest
+	var errs []error
+	if pk, e := val.ConsPubKey(); e != nil {
+		// the SDK error carries the source line it was raised at, which means nothing to the reader
+		errs = append(errs, fmt.Errorf("Could not decode consensus key of type %q", val.ConsensusPubkey.GetTypeUrl()))
+	} else {
+		validator.ConsensusPubkey = base64.StdEncoding.EncodeToString(pk.Bytes())
+		validator.ConsensusPubkeyType = val.ConsensusPubkey.TypeUrl
+		validator.ConsensusAddress, err = bech32.ConvertAndEncode(prefix+"valcons", pk.Address())
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			validator.Signing, err = querySigningInfo(ctx, q, validator.ConsensusAddress)
+			// validators that have not signed a block yet have no signing info
+			if err != nil && !errors.Is(err, client.ErrNotFound) {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	bondDenom, err := q.BondDenom(ctx)
+	if err != nil {
+		errs = append(errs, err)
+	} else {
+		validator.Tokens = toCoin(chain, sdk.Coin{Denom: bondDenom, Amount: val.Tokens})
+	}
+
+	if val.Status != staketypes.Bonded {
+		return validator, joinErrors(errs)
+	}
+	active, err := q.BondedValidators(ctx)
+	if err != nil {
+		errs = append(errs, err)
+		return validator, joinErrors(errs)
+	}
+	// validators with equal tokens are ranked by operator address so the rank does not change between runs
+	sort.Slice(active, func(i, j int) bool {
+		if !active[i].Tokens.Equal(active[j].Tokens) {
+			return active[i].Tokens.GT(active[j].Tokens)
+		}
+		return active[i].OperatorAddress < active[j].OperatorAddress
+	})
+	for i := range active {
+		if active[i].OperatorAddress == val.OperatorAddress {
+			validator.Rank = i + 1
+			break
+		}
+	}
+	return validator, joinErrors(errs)
+}
+
+// querySigningInfo combines the signing info of a validator with the slashing params of the chain so
+// the missed block counter can be read against the window it applies to
+func querySigningInfo(ctx context.Context, q client.ChainQuerier, consAddress string) (*SigningInfo, error) {
+	info, err := q.SigningInfo(ctx, consAddress)
+	if err != nil {
+		return nil, err
+	}
+	params, err := q.SlashingParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &SigningInfo{
+		MissedBlocks:       info.MissedBlocksCounter,
+		SignedBlocksWindow: params.SignedBlocksWindow,
+		MinSignedPerWindow: params.MinSignedPerWindow.String(),
+		JailedUntil:        info.JailedUntil,
+		Tombstoned:         info.Tombstoned,
+	}, nil
+}
@@ -4,9 +4,10 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
-	"io"
+	"io/fs"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"github.com/johnsaigle/findaccount/types"
 )
@@ -20,6 +21,11 @@ var (
 	// StaticFs embed.FS
 
 	Infos = make(map[string]*types.ChainInfo)
+	// Assets holds the contents of assetlist.json for every chain in Infos that has one
+	Assets = make(map[string]*types.AssetList)
+
+	// assetIndex maps a chain name and a denom to the asset it belongs to
+	assetIndex = make(map[string]map[string]*types.Asset)
 )
 
 // TODO I would like to remove the init function because I don't know if there is a good way to do error handling
@@ -29,58 +35,155 @@ func init() {
 	log.SetFlags(log.Lshortfile)
 
 	// The chain-registry directory is a submodule to https://github.com/cosmos/chain-registry/
-	registryFiles, err := chainsFs.ReadDir("chain-registry")
+	registry, err := fs.Sub(chainsFs, "chain-registry")
+	if err != nil {
+		panic("Could not read chain-registry directory. No way to recover")
+	}
+	err = Load(registry)
 	if err != nil {
 		panic("Could not read chain-registry directory. No way to recover")
 	}
 
+	// add extra known-good RPC servers....
+	for name, prefix := range additional {
+		if Infos[name] == nil {
+			log.Println(name, "is not defined skipping addition of RPC")
+			continue
+		}
+		if Infos[name].Apis.Rpc == nil {
+			Infos[name].Apis.Rpc = make([]types.Rpc, 0)
+		}
+		for _, node := range prefix {
+			Infos[name].Apis.Rpc = append(Infos[name].Apis.Rpc, types.Rpc{Address: node})
+		}
+	}
+
+}
+
+// Load replaces the chains in Infos and Assets with those of the chain-registry in fsys, which holds a
+// directory with a chain.json, and optionally an assetlist.json, per chain. It is used to search a
+// registry other than the embedded one, e.g. a synthetic registry for offline tests, and should be
+// called before the first search.
+func Load(fsys fs.FS) error {
+	registryFiles, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return fmt.Errorf("Could not read chain-registry directory: %w", err)
+	}
+	for name := range Infos {
+		delete(Infos, name)
+	}
+	for name := range Assets {
+		delete(Assets, name)
+	}
+	for name := range assetIndex {
+		delete(assetIndex, name)
+	}
+
 	for _, entry := range registryFiles {
 		// We want directories that do not start with an underscore or period 
 		if !entry.IsDir() {
 			continue
 		} 
 		name := entry.Name()
-		// NOTE: embedFS regex pattern above might take care of this for us..
 		if strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
 			continue
 		}
 
-		f, e := chainsFs.Open(fmt.Sprintf("chain-registry/%s/chain.json", name))
-		if e != nil {
-			log.Println(e)
-			continue
-		}
-		b, e := io.ReadAll(f)
+		b, e := fs.ReadFile(fsys, name+"/chain.json")
 		if e != nil {
 			log.Println(e)
 			continue
 		}
-		defer f.Close()
 		chainInfo := &types.ChainInfo{}
 		e = json.Unmarshal(b, chainInfo)
 		if e != nil {
 			log.Println(e)
 			continue
 		}
-		if chainInfo != nil && len(chainInfo.Apis.Rpc) > 0 {
+		// chains are searched through gRPC or REST when none of their RPC endpoints answer, so any kind
+		// of endpoint will do
+		apis := chainInfo.Apis
+		if len(apis.Rpc) > 0 || len(apis.Grpc) > 0 || len(apis.Rest) > 0 {
 			Infos[name] = chainInfo
 		}
+
+		// Not every chain publishes an assetlist, so a missing file is not an error
+		b, e = fs.ReadFile(fsys, name+"/assetlist.json")
+		if e != nil {
+			continue
+		}
+		assetList := &types.AssetList{}
+		e = json.Unmarshal(b, assetList)
+		if e != nil {
+			log.Println(e)
+			continue
+		}
+		Assets[name] = assetList
+		indexAssets(name, assetList)
 	}
+	return nil
+}
 
-	// add extra known-good RPC servers....
-	for name, prefix := range additional {
-		if Infos[name] == nil {
-			log.Println(name, "is not defined skipping addition of RPC")
+// Write saves Infos and Assets to dir in the layout Load reads, so that a search can later be repeated
+// against the same chains
+func Write(dir string) error {
+	for name, info := range Infos {
+		err := os.MkdirAll(filepath.Join(dir, name), 0o755)
+		if err != nil {
+			return fmt.Errorf("Could not create chain-registry directory: %w", err)
+		}
+		b, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return fmt.Errorf("Could not encode chain.json of %s: %w", name, err)
+		}
+		err = os.WriteFile(filepath.Join(dir, name, "chain.json"), b, 0o644)
+		if err != nil {
+			return fmt.Errorf("Could not write chain.json of %s: %w", name, err)
+		}
+		if Assets[name] == nil {
 			continue
 		}
-		if Infos[name].Apis.Rpc == nil {
-			Infos[name].Apis.Rpc = make([]types.Rpc, 0)
+		b, err = json.MarshalIndent(Assets[name], "", "  ")
+		if err != nil {
+			return fmt.Errorf("Could not encode assetlist.json of %s: %w", name, err)
 		}
-		for _, node := range prefix {
-			Infos[name].Apis.Rpc = append(Infos[name].Apis.Rpc, types.Rpc{Address: node})
+		err = os.WriteFile(filepath.Join(dir, name, "assetlist.json"), b, 0o644)
+		if err != nil {
+			return fmt.Errorf("Could not write assetlist.json of %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// indexAssets records every asset of a chain under its base denom and under the aliases of its base
+// denom unit, so that lookups work for both ibc/ hashes and the micro-denoms they are often listed as.
+func indexAssets(chain string, assetList *types.AssetList) {
+	index := make(map[string]*types.Asset, len(assetList.Assets))
+	for i := range assetList.Assets {
+		asset := &assetList.Assets[i]
+		for _, unit := range asset.DenomUnits {
+			if unit.Denom != asset.Base {
+				continue
+			}
+			for _, alias := range unit.Aliases {
+				if _, ok := index[alias]; !ok {
+					index[alias] = asset
+				}
+			}
 		}
 	}
+	// base denoms take precedence over aliases
+	for i := range assetList.Assets {
+		index[assetList.Assets[i].Base] = &assetList.Assets[i]
+	}
+	assetIndex[chain] = index
+}
 
+// LookupAsset returns the chain-registry metadata for denom as held on chain. The denom is normally
+// the base denom (e.g. uatom or ibc/27394FB0...) but aliases of the base unit are also accepted.
+func LookupAsset(chain, denom string) (*types.Asset, bool) {
+	asset, ok := assetIndex[chain][denom]
+	return asset, ok
 }
 
 // Additional known nodes, not all nodes from cosmos repo are live....
@@ -96,73 +199,3 @@ var additional = map[string][]string{
 	// "kava":          {"https://rpc.data.kava.io:443"},
 	// "stargaze":      {"https://rpc.stargaze-apis.com:443"},
 }
-
-// Prefixes maps the chain name to the bech32 address prefix.
-// TODO: validate these are all correct!!!
-// TODO: delete anything that is in the chainlist. move any remaining to 'additional' for now
-var Prefixes = map[string]string{
-	"agoric":         "agoric",
-	"akash":          "akash",
-	"assetmantle":    "mantle",
-	"axelar":         "axelar",
-	"bandchain":      "band",
-	"bitcanna":       "bcn",
-	"bitsong":        "bitsong",
-	"bostrom":        "bostrom",
-	"carbon":         "carbon",
-	"cerberus":       "cerberus",
-	"cheqd":          "cheqd",
-	"chihuahua":      "chihuahua",
-	"comdex":         "comdex",
-	"cosmoshub":      "cosmos",
-	"crescent":       "cre",
-	"cronos":         "cro",
-	"cryptoorgchain": "cro",
-	"decentr":        "decentr",
-	"desmos":         "desmos",
-	"dig":            "dig",
-	"emoney":         "emoney",
-	"evmos":          "evmos",
-	"fetchhub":       "fetchhub",
-	"firmachain":     "firma",
-	"galaxy":         "galaxy",
-	"genesisl1":      "genesis",
-	"gravitybridge":  "gravity",
-	"impacthub":      "impact",
-	"injective":      "inj",
-	"irisnet":        "i",
-	"juno":           "juno",
-	"kava":           "kava",
-	"kichain":        "ki",
-	"konstellation":  "darc",
-	"likecoin":       "like",
-	"meme":           "meme",
-	"microtick":      "micro",
-	"nomic":          "nomic",
-	"octa":           "octa",
-	"odin":           "odin",
-	"oraichain":      "orai",
-	"osmosis":        "osmo",
-	"panacea":        "panacea",
-	"persistence":    "persistence",
-	"provenance":     "pb",
-	"regen":          "regen",
-	"rizon":          "rizon",
-	"secretnetwork":  "secret",
-	"sentinel":       "sent",
-	"shentu":         "certic",
-	"sifchain":       "sif",
-	"sommelier":      "somm",
-	"stargaze":       "stars",
-	"starname":       "star",
-	"terra":          "terra",
-	"umee":           "umee",
-	"vidulum":        "vdl",
-	// not working!!!
-	//"arkh":           "arkh", //this is a testnet?
-	//"echelon":        "echelon",
-	//"logos":          "logos",
-	//"lumnetwork":     "lum",
-	//"mythos":         "mythos",
-	//"thorchain":      "thor",
-}
@@ -0,0 +1,24 @@
+package client
+
+import (
+	"context"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+)
+
+// QueryAuthAccount returns the x/auth record for address. The raw Any is always returned so that account
+// types this tool does not know about (e.g. Ethermint accounts) can still be identified by type URL;
+// account is only set when the type could be decoded. ErrNotFound is returned if the account has never
+// been created on the chain.
+func QueryAuthAccount(ctx context.Context, client Transport, address string) (raw *codectypes.Any, account authtypes.AccountI, err error) {
+	resp := authtypes.QueryAccountResponse{}
+	err = client.Query(ctx, "/cosmos.auth.v1beta1.Query/Account", &authtypes.QueryAccountRequest{Address: address}, &resp)
+	if err != nil || resp.Account == nil {
+		return
+	}
+	raw = resp.Account
+	if e := interfaceRegistry.UnpackAny(resp.Account, &account); e != nil {
+		account = nil
+	}
+	return
+}
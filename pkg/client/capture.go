@@ -0,0 +1,418 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// manifestFile holds the Recording in a capture directory. The Capture of each chain is written to
+// <chain>.json in chainsDir, apart from the manifest, so no chain name can clash with it.
+const (
+	manifestFile = "recording.json"
+	chainsDir    = "chains"
+)
+
+// Recording describes a capture directory
+type Recording struct {
+	// Time is when the recording was made. Results that depend on the current time, such as how much of
+	// a vesting account is still locked, are computed at Time when recording and when replaying.
+	Time time.Time `json:"time"`
+}
+
+// Capture holds every connection attempt and query made to a chain during a recording. Attempts made in
+// the same scope keep the order they were made in.
+type Capture struct {
+	Chain    string            `json:"chain"`
+	Connects []CapturedConnect `json:"connects"`
+	Queries  []CapturedQuery   `json:"queries"`
+}
+
+// CapturedConnect is the outcome of connecting to a chain: the transport that was used, or the error
+// no client could be built with. Kind is set as for CapturedQuery.
+type CapturedConnect struct {
+	Scope     string `json:"scope,omitempty"`
+	Transport string `json:"transport,omitempty"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+}
+
+// CapturedQuery is a request sent to a chain together with its reply, as protobuf bytes, or the error
+// the query failed with
+type CapturedQuery struct {
+	// Scope is the scope the query was sent in, see WithScope
+	Scope    string `json:"scope,omitempty"`
+	Path     string `json:"path"`
+	Request  []byte `json:"request"`
+	Response []byte `json:"response,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// Kind is not_found or unknown_query when Error wraps ErrNotFound or ErrUnknownQuery, and
+	// deadline_exceeded or canceled when the query was cut short by the end of the search
+	Kind string `json:"kind,omitempty"`
+}
+
+const (
+	kindNotFound         = "not_found"
+	kindUnknownQuery     = "unknown_query"
+	kindDeadlineExceeded = "deadline_exceeded"
+	kindCanceled         = "canceled"
+)
+
+// errorKind returns the Kind of err, a query that failed while ctx was running
+func errorKind(ctx context.Context, err error) string {
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return kindDeadlineExceeded
+	case ctx.Err() != nil:
+		return kindCanceled
+	case errors.Is(err, ErrNotFound):
+		return kindNotFound
+	case errors.Is(err, ErrUnknownQuery):
+		return kindUnknownQuery
+	default:
+		return ""
+	}
+}
+
+func queryKey(path string, request []byte) string {
+	return path + " " + hex.EncodeToString(request)
+}
+
+type scopeKey struct{}
+
+// WithScope returns a context whose connections and queries are recorded apart from those made in other
+// scopes, e.g. the address a search is for. Several searches can send the same query to the same chain
+// and get different replies, such as a timeout for one and an answer for the other; each of them is
+// replayed with its own.
+func WithScope(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+func scopeOf(ctx context.Context) string {
+	scope, _ := ctx.Value(scopeKey{}).(string)
+	return scope
+}
+
+// capturedError recreates a recorded error with the same message and sentinel
+type capturedError struct {
+	msg  string
+	kind string
+}
+
+func (e capturedError) Error() string {
+	return e.msg
+}
+
+func (e capturedError) Unwrap() error {
+	switch e.kind {
+	case kindNotFound:
+		return ErrNotFound
+	case kindUnknownQuery:
+		return ErrUnknownQuery
+	case kindDeadlineExceeded:
+		return context.DeadlineExceeded
+	case kindCanceled:
+		return context.Canceled
+	default:
+		return nil
+	}
+}
+
+// Recorder captures the queries sent through the transports it wraps, to be written out with Save
+type Recorder struct {
+	dir       string
+	recording Recording
+
+	mux      sync.Mutex
+	captures map[string]*Capture
+}
+
+// NewRecorder returns a Recorder saving to dir, which is created if needed
+func NewRecorder(dir string) (*Recorder, error) {
+	err := os.MkdirAll(filepath.Join(dir, chainsDir), 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("Could not create recording directory: %w", err)
+	}
+	return &Recorder{
+		dir:       dir,
+		recording: Recording{Time: time.Now().UTC()},
+		captures:  make(map[string]*Capture),
+	}, nil
+}
+
+// Time is the time of the recording, see Recording
+func (r *Recorder) Time() time.Time {
+	return r.recording.Time
+}
+
+// Transport records the outcome of connecting to chain: transport and err as returned by the connection
+// attempt made under ctx. When err is nil the returned Transport records every query sent through it.
+func (r *Recorder) Transport(ctx context.Context, chain string, transport Transport, err error) (Transport, error) {
+	connect := CapturedConnect{Scope: scopeOf(ctx)}
+	if err != nil {
+		connect.Error, connect.Kind = err.Error(), errorKind(ctx, err)
+	} else {
+		connect.Transport, connect.Endpoint = transport.Name(), transport.Endpoint()
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	capture := r.capture(chain)
+	capture.Connects = append(capture.Connects, connect)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingTransport{Transport: transport, recorder: r, chain: chain}, nil
+}
+
+// capture returns the Capture of chain, creating it if needed. The caller holds r.mux.
+func (r *Recorder) capture(chain string) *Capture {
+	capture := r.captures[chain]
+	if capture == nil {
+		capture = &Capture{Chain: chain}
+		r.captures[chain] = capture
+	}
+	return capture
+}
+
+func (r *Recorder) add(chain string, query CapturedQuery) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	capture := r.capture(chain)
+	capture.Queries = append(capture.Queries, query)
+}
+
+// Save writes the recording to its directory. Attempts are sorted by scope and query, keeping the order
+// they were made in, so that recordings of the same chain state are identical.
+func (r *Recorder) Save() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for chain, capture := range r.captures {
+		sort.SliceStable(capture.Connects, func(i, j int) bool {
+			return capture.Connects[i].Scope < capture.Connects[j].Scope
+		})
+		sort.SliceStable(capture.Queries, func(i, j int) bool {
+			qi, qj := capture.Queries[i], capture.Queries[j]
+			if ki, kj := queryKey(qi.Path, qi.Request), queryKey(qj.Path, qj.Request); ki != kj {
+				return ki < kj
+			}
+			return qi.Scope < qj.Scope
+		})
+		err := writeJSON(filepath.Join(r.dir, chainsDir, chain+".json"), capture)
+		if err != nil {
+			return err
+		}
+	}
+	return writeJSON(filepath.Join(r.dir, manifestFile), r.recording)
+}
+
+func writeJSON(path string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Could not encode %s: %w", filepath.Base(path), err)
+	}
+	err = os.WriteFile(path, b, 0o644)
+	if err != nil {
+		return fmt.Errorf("Could not write recording: %w", err)
+	}
+	return nil
+}
+
+// recordingTransport passes queries on to the wrapped Transport and records them
+type recordingTransport struct {
+	Transport
+	recorder *Recorder
+	chain    string
+}
+
+func (t *recordingTransport) Query(ctx context.Context, path string, req, resp ProtoMessage) error {
+	err := t.Transport.Query(ctx, path, req, resp)
+	request, e := req.Marshal()
+	if e != nil {
+		return err
+	}
+	// queries cut short by the end of the search are recorded too, so the search ends the same way on
+	// replay
+	query := CapturedQuery{Scope: scopeOf(ctx), Path: path, Request: request}
+	if err != nil {
+		query.Error, query.Kind = err.Error(), errorKind(ctx, err)
+	} else {
+		query.Response, e = resp.Marshal()
+		if e != nil {
+			return err
+		}
+	}
+	t.recorder.add(t.chain, query)
+	return err
+}
+
+// Replayer serves the queries of a recording instead of the network
+type Replayer struct {
+	recording Recording
+	chains    map[string]*replayChain
+}
+
+// attemptKey identifies the attempts of a scope to connect, with an empty query, or to send a query
+type attemptKey struct {
+	scope string
+	query string
+}
+
+// replayChain serves the recorded attempts of a chain. The attempts of a scope are replayed in the order
+// they were made, the last one answering any further attempts.
+type replayChain struct {
+	chain    string
+	connects map[string][]CapturedConnect
+	replies  map[attemptKey][]CapturedQuery
+	// firstConnect and firstReplies hold the first successful attempt whatever its scope. Replies that
+	// are cached across searches, such as the bond denom, are only sent in the scope that asked first.
+	firstConnect *CapturedConnect
+	firstReplies map[string]CapturedQuery
+
+	mux    sync.Mutex
+	served map[attemptKey]int
+}
+
+// next returns the index of the attempt to replay for key, out of n recorded ones
+func (c *replayChain) next(key attemptKey, n int) int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	i := c.served[key]
+	c.served[key]++
+	if i >= n {
+		i = n - 1
+	}
+	return i
+}
+
+// NewReplayer loads the recording in dir
+func NewReplayer(dir string) (*Replayer, error) {
+	r := &Replayer{chains: make(map[string]*replayChain)}
+	b, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("Could not read recording: %w", err)
+	}
+	err = json.Unmarshal(b, &r.recording)
+	if err != nil {
+		return nil, fmt.Errorf("Could not decode %s: %w", manifestFile, err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, chainsDir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("Could not read recording: %w", err)
+	}
+	for _, file := range files {
+		b, err = os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read recording: %w", err)
+		}
+		capture := &Capture{}
+		err = json.Unmarshal(b, capture)
+		if err != nil {
+			return nil, fmt.Errorf("Could not decode %s: %w", filepath.Base(file), err)
+		}
+		c := &replayChain{
+			chain:        capture.Chain,
+			connects:     make(map[string][]CapturedConnect),
+			replies:      make(map[attemptKey][]CapturedQuery),
+			firstReplies: make(map[string]CapturedQuery),
+			served:       make(map[attemptKey]int),
+		}
+		for i, connect := range capture.Connects {
+			c.connects[connect.Scope] = append(c.connects[connect.Scope], connect)
+			if connect.Error == "" && c.firstConnect == nil {
+				c.firstConnect = &capture.Connects[i]
+			}
+		}
+		for _, q := range capture.Queries {
+			key := queryKey(q.Path, q.Request)
+			c.replies[attemptKey{q.Scope, key}] = append(c.replies[attemptKey{q.Scope, key}], q)
+			if _, ok := c.firstReplies[key]; !ok && q.Error == "" {
+				c.firstReplies[key] = q
+			}
+		}
+		r.chains[capture.Chain] = c
+	}
+	return r, nil
+}
+
+// Time is the time of the recording, see Recording
+func (r *Replayer) Time() time.Time {
+	return r.recording.Time
+}
+
+// Transport returns a Transport answering the recorded queries of chain, or the error connecting to it
+// failed with, for the scope of ctx
+func (r *Replayer) Transport(ctx context.Context, chain string) (Transport, error) {
+	c, ok := r.chains[chain]
+	if !ok {
+		return nil, fmt.Errorf("%s was not recorded", chain)
+	}
+	scope := scopeOf(ctx)
+	var connect CapturedConnect
+	switch connects := c.connects[scope]; {
+	case len(connects) > 0:
+		connect = connects[c.next(attemptKey{scope: scope}, len(connects))]
+	case c.firstConnect != nil:
+		connect = *c.firstConnect
+	default:
+		return nil, fmt.Errorf("%s was not recorded", chain)
+	}
+	if connect.Error != "" {
+		return nil, capturedError{msg: connect.Error, kind: connect.Kind}
+	}
+	return &replayTransport{chain: c, name: connect.Transport, endpoint: connect.Endpoint}, nil
+}
+
+type replayTransport struct {
+	chain    *replayChain
+	name     string
+	endpoint string
+}
+
+func (t *replayTransport) Name() string {
+	return t.name
+}
+
+func (t *replayTransport) Endpoint() string {
+	return t.endpoint
+}
+
+func (t *replayTransport) Check(ctx context.Context) error {
+	return nil
+}
+
+func (t *replayTransport) Query(ctx context.Context, path string, req, resp ProtoMessage) error {
+	request, err := req.Marshal()
+	if err != nil {
+		return fmt.Errorf("Could not marshal request for %s: %w", path, err)
+	}
+	key := attemptKey{scopeOf(ctx), queryKey(path, request)}
+	var reply CapturedQuery
+	if replies := t.chain.replies[key]; len(replies) > 0 {
+		reply = replies[t.chain.next(key, len(replies))]
+	} else if first, ok := t.chain.firstReplies[key.query]; ok {
+		reply = first
+	} else {
+		return fmt.Errorf("%s with request %s was not recorded for %s", path, strings.ToUpper(hex.EncodeToString(request)), t.chain.chain)
+	}
+	if reply.Error != "" {
+		return capturedError{msg: reply.Error, kind: reply.Kind}
+	}
+	if len(reply.Response) == 0 {
+		return nil
+	}
+	err = resp.Unmarshal(reply.Response)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal response for %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+// slowTransport answers queries once ctx has ended
+type slowTransport struct{}
+
+func (slowTransport) Query(ctx context.Context, path string, req, resp ProtoMessage) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (slowTransport) Check(ctx context.Context) error { return nil }
+func (slowTransport) Name() string                    { return TransportRPC }
+func (slowTransport) Endpoint() string                { return "http://127.0.0.1:26657" }
+
+// balancesTransport answers every query with its balances
+type balancesTransport struct {
+	slowTransport
+	balances *banktypes.QueryAllBalancesResponse
+}
+
+func (t balancesTransport) Query(ctx context.Context, path string, req, resp ProtoMessage) error {
+	b, err := t.balances.Marshal()
+	if err != nil {
+		return err
+	}
+	return resp.Unmarshal(b)
+}
+
+func TestReplayUnfinishedQueries(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	transport, err := recorder.Transport(expired, "cosmoshub", slowTransport{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &banktypes.QueryAllBalancesRequest{Address: "cosmos1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twlpvf6m"}
+	err = transport.Query(expired, "/cosmos.bank.v1beta1.Query/AllBalances", req, &banktypes.QueryAllBalancesResponse{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the query to time out", err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = recorder.Transport(canceled, "osmosis", nil, errors.New("could not connect to any endpoints for osmosis"))
+	if err == nil {
+		t.Fatal("the connection error was not returned")
+	}
+	if err = recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	replayer, err := NewReplayer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport, err = replayer.Transport(context.Background(), "cosmoshub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = transport.Query(context.Background(), "/cosmos.bank.v1beta1.Query/AllBalances", req, &banktypes.QueryAllBalancesResponse{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v on replay, want context.DeadlineExceeded", err)
+	}
+	_, err = replayer.Transport(context.Background(), "osmosis")
+	if !errors.Is(err, context.Canceled) || err.Error() != "could not connect to any endpoints for osmosis" {
+		t.Errorf("got %v connecting on replay, want the recorded error wrapping context.Canceled", err)
+	}
+}
+
+func TestReplayChainNamedLikeTheManifest(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = recorder.Transport(context.Background(), "recording", nil, errors.New("could not connect to any endpoints for recording"))
+	if err == nil {
+		t.Fatal("the connection error was not returned")
+	}
+	if err = recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	replayer, err := NewReplayer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !replayer.Time().Equal(recorder.Time()) {
+		t.Errorf("got recording time %s, want %s", replayer.Time(), recorder.Time())
+	}
+	_, err = replayer.Transport(context.Background(), "recording")
+	if err == nil || err.Error() != "could not connect to any endpoints for recording" {
+		t.Errorf("got %v connecting on replay, want the recorded error", err)
+	}
+}
+
+func TestReplayRepeatedAttempts(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const path = "/cosmos.bank.v1beta1.Query/AllBalances"
+	req := &banktypes.QueryAllBalancesRequest{Address: "cosmos1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twlpvf6m"}
+	balances := &banktypes.QueryAllBalancesResponse{Balances: sdk.NewCoins(sdk.NewInt64Coin("uatom", 1000))}
+
+	// the first search fails to connect, then times out, then is answered
+	first := WithScope(context.Background(), "first")
+	_, err = recorder.Transport(first, "cosmoshub", nil, errors.New("could not connect to any endpoints for cosmoshub"))
+	if err == nil {
+		t.Fatal("the connection error was not returned")
+	}
+	expired, cancel := context.WithTimeout(first, 0)
+	defer cancel()
+	transport, err := recorder.Transport(expired, "cosmoshub", slowTransport{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = transport.Query(expired, path, req, &banktypes.QueryAllBalancesResponse{})
+	transport, err = recorder.Transport(first, "cosmoshub", balancesTransport{balances: balances}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = transport.Query(first, path, req, &banktypes.QueryAllBalancesResponse{}); err != nil {
+		t.Fatal(err)
+	}
+	// the second search times out on its only attempt
+	second, cancel := context.WithTimeout(WithScope(context.Background(), "second"), 0)
+	defer cancel()
+	transport, err = recorder.Transport(second, "cosmoshub", slowTransport{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = transport.Query(second, path, req, &banktypes.QueryAllBalancesResponse{})
+	if err = recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	replayer, err := NewReplayer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first = WithScope(context.Background(), "first")
+	if _, err = replayer.Transport(first, "cosmoshub"); err == nil {
+		t.Error("the failed connection replayed as a success")
+	}
+	transport, err = replayer.Transport(first, "cosmoshub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := &banktypes.QueryAllBalancesResponse{}
+	if err = transport.Query(first, path, req, resp); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v for the first attempt, want context.DeadlineExceeded", err)
+	}
+	if err = transport.Query(first, path, req, resp); err != nil || !resp.Balances.IsEqual(balances.Balances) {
+		t.Errorf("got %v and balances %s for the second attempt, want %s", err, resp.Balances, balances.Balances)
+	}
+
+	second = WithScope(context.Background(), "second")
+	transport, err = replayer.Transport(second, "cosmoshub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = transport.Query(second, path, req, resp); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v for the second search, want context.DeadlineExceeded", err)
+	}
+
+	// a search that was not recorded is answered with the first successful attempt
+	transport, err = replayer.Transport(context.Background(), "cosmoshub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = &banktypes.QueryAllBalancesResponse{}
+	if err = transport.Query(context.Background(), path, req, resp); err != nil || !resp.Balances.IsEqual(balances.Balances) {
+		t.Errorf("got %v and balances %s for another search, want %s", err, resp.Balances, balances.Balances)
+	}
+}
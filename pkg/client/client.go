@@ -5,193 +5,104 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
-	"strings"
 
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	cryptocodec "github.com/cosmos/cosmos-sdk/crypto/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/bech32"
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	vestingtypes "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
-	staketypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 	"github.com/johnsaigle/findaccount/types"
-	findaccounttypes "github.com/johnsaigle/findaccount/types"
 	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
 )
 
 var portRex = regexp.MustCompile(`.*:\d+$`)
 var protoRex = regexp.MustCompile(`^\w+://`)
 
-// TODO adding REST API support would be nice for nodes that do not have RPC enabled
-func NewClient(rpcaddress string) (*rpchttp.HTTP, error) {
-	client := &rpchttp.HTTP{}
-	var err error
-	rpcaddress= strings.TrimRight(rpcaddress, "/")
-	var unknown bool
+// ErrNotFound is returned by queries for objects, such as accounts, that the chain does not know about
+var ErrNotFound = errors.New("not found")
 
-	if !portRex.MatchString(rpcaddress) {
-		switch protoRex.FindString(rpcaddress) {
-		case "https://":
-			rpcaddress = rpcaddress + ":443"
-		case "http://":
-			rpcaddress = rpcaddress + ":80"
-		case "tcp://":
-			rpcaddress = rpcaddress + ":26657"
-		default:
-			unknown = true
-		}
-	}
-	if unknown {
-		err = errors.New("Unknown protocol")
-		return nil, err
-	}
-	client, err = rpchttp.NewWithTimeout(rpcaddress, "/websocket", 10)
-	if err != nil {
-		return nil, err
-	}
-	status, err := client.Status(context.Background())
-	if err != nil || status.SyncInfo.CatchingUp {
-		return nil, err
-	}
-	return client, err
-}
+// ErrUnknownQuery is returned when the chain does not run the module serving the query path
+var ErrUnknownQuery = errors.New("unknown query path")
 
-func NewClientFromChainInfo(rpcs []types.Rpc, chain string) (*rpchttp.HTTP, error) {
-	client := &rpchttp.HTTP{}
-	var err error
-	ok := false
-	for i := range rpcs {
-		endpoint := rpcs[len(rpcs)-1-i]
-		endpoint.Address = strings.TrimRight(endpoint.Address, "/")
-		var unknown bool
+// interfaceRegistry knows the public key types, which is needed to decode the Any fields in query responses
+var interfaceRegistry = newInterfaceRegistry()
 
-		if !portRex.MatchString(endpoint.Address) {
-			switch protoRex.FindString(endpoint.Address) {
-			case "https://":
-				endpoint.Address = endpoint.Address + ":443"
-			case "http://":
-				endpoint.Address = endpoint.Address + ":80"
-			case "tcp://":
-				endpoint.Address = endpoint.Address + ":26657"
-			default:
-				unknown = true
-			}
-		}
-		if unknown {
-			err = errors.New("Unknown protocol")
-			continue
-		}
-		client, err = rpchttp.NewWithTimeout(endpoint.Address, "/websocket", 10)
-		if err != nil {
-			continue
-		}
-		status, err := client.Status(context.Background())
-		if err != nil || status.SyncInfo.CatchingUp {
-			continue
-		}
-		ok = true
-		break
-	}
-	if !ok {
-		err = fmt.Errorf("could not connect to any endpoints for %s: %w", chain, err)
-	}
-	return client, err
+func newInterfaceRegistry() codectypes.InterfaceRegistry {
+	registry := codectypes.NewInterfaceRegistry()
+	cryptocodec.RegisterInterfaces(registry)
+	authtypes.RegisterInterfaces(registry)
+	vestingtypes.RegisterInterfaces(registry)
+	return registry
 }
 
-// TODO change to accept a client as parameter rather than build one. this function queries a single
-// RPC endpoint anyway; it doesn't need to build the client.
-func IsValidator(client rpchttp.HTTP, account, prefix string) (validator string, err error) {
-	// client, err := NewClientFromChainInfo(info, chain)
-	// if err != nil {
-	// 	return
-	// }
-	// Check if the account is also a validator
-	_, b64, err := bech32.DecodeAndConvert(account)
+// NewClient connects to a single RPC endpoint and checks it is in sync. Chains from the chain-registry
+// should be reached through a Pool, which falls back to gRPC and REST endpoints.
+func NewClient(ctx context.Context, rpcaddress string) (*rpchttp.HTTP, error) {
+	rpcaddress, err := normalizeAddress(rpcaddress)
 	if err != nil {
-		return
+		return nil, err
 	}
-	// accountsMux.Lock()
-	// // FIXME remove Prefixes and replace with chainInfo
-	// prefix := Prefixes[chain]
-	// accountsMux.Unlock()
-	addr, _ := bech32.ConvertAndEncode(prefix+"valoper", b64)
-	valQ := staketypes.QueryValidatorRequest{ValidatorAddr: addr}
-	valQuery, err := valQ.Marshal()
+	client, err := rpchttp.NewWithTimeout(rpcaddress, "/websocket", 10)
 	if err != nil {
-		return
+		return nil, err
 	}
-	valResult, err := client.ABCIQuery(context.Background(), "/cosmos.staking.v1beta1.Query/Validator", valQuery)
+	status, err := client.Status(ctx)
 	if err != nil {
-		return
+		return nil, err
 	}
-	if len(valResult.Response.Value) > 0 {
-		valResp := staketypes.QueryValidatorResponse{}
-		err = valResp.Unmarshal(valResult.Response.Value)
-		if err != nil {
-			return
-		}
-		validator = valResp.Validator.GetMoniker()
-		//fmt.Println(valResp)
-
+	if status.SyncInfo.CatchingUp {
+		return nil, errors.New("node is catching up")
 	}
-	return
+	return client, nil
 }
 
-func QueryAccountFromChainInfo(client rpchttp.HTTP, info *findaccounttypes.ChainInfo, account string) (hasBalance bool, balances string, err error) {
-	q := banktypes.QueryBalanceRequest{Address: account}
-	var query []byte
-	query, err = q.Marshal()
-	if err != nil {
-		err = fmt.Errorf("Could not marshal QueryBalanceRequest: %w", err)
-		return
+// NewClientFromChainInfo probes every RPC endpoint of the chain at once and returns a client for the
+// best ranked healthy one, see ProbeChain
+func NewClientFromChainInfo(ctx context.Context, rpcs []types.Rpc, chain string) (*rpchttp.HTTP, error) {
+	ranking, clients := ProbeChain(ctx, chain, rpcs)
+	if len(ranking) == 0 {
+		return nil, fmt.Errorf("could not connect to any endpoints for %s: no RPC endpoints known", chain)
 	}
-	result, err := client.ABCIQuery(context.Background(), "/cosmos.bank.v1beta1.Query/AllBalances", query)
-	if err != nil {
-		err = fmt.Errorf("Could not complete ABCIQuery: %w", err)
-		return
+	if !ranking[0].Healthy {
+		return nil, fmt.Errorf("could not connect to any endpoints for %s: %s", chain, ranking[0].Error)
 	}
-
-	if len(result.Response.Value) > 0 {
-		balResp := banktypes.QueryBalanceResponse{}
-		err = balResp.Unmarshal(result.Response.Value)
-		if err != nil {
-			err = fmt.Errorf("Could not unmarshal QueryBalanceResponse: %w", err)
-			return
-		}
-		if balResp.Balance != nil {
-			balances = balResp.Balance.String()
-			hasBalance = true
-		}
-	}
-
-	return
+	return clients[ranking[0].Address], nil
 }
 
-// TODO change to accept a client as parameter rather than build one. this function queries a single
-// RPC endpoint anyway; it doesn't need to build the client.
-func QueryAccount(client rpchttp.HTTP, account string) (hasBalance bool, balances string, err error) {
-
-	q := banktypes.QueryBalanceRequest{Address: account}
-	var query []byte
-	query, err = q.Marshal()
-	if err != nil {
-		err = fmt.Errorf("Could not marshal QueryBalanceRequest: %w", err)
-		return
-	}
-	result, err := client.ABCIQuery(context.Background(), "/cosmos.bank.v1beta1.Query/AllBalances", query)
+// ValoperAddress re-encodes an account address as the operator address of the validator it would control
+func ValoperAddress(account, prefix string) (string, error) {
+	_, b64, err := bech32.DecodeAndConvert(account)
 	if err != nil {
-		err = fmt.Errorf("Could not complete ABCIQuery: %w", err)
-		return
+		return "", err
 	}
+	return bech32.ConvertAndEncode(prefix+"valoper", b64)
+}
 
-	if len(result.Response.Value) > 0 {
-		balResp := banktypes.QueryBalanceResponse{}
-		err = balResp.Unmarshal(result.Response.Value)
+// QueryAccount returns every coin held by account, following the pagination of the AllBalances query
+// until the node reports there are no more pages.
+func QueryAccount(ctx context.Context, client Transport, account string) (hasBalance bool, balances sdk.Coins, err error) {
+	var nextKey []byte
+	for {
+		q := banktypes.QueryAllBalancesRequest{
+			Address:    account,
+			Pagination: &querytypes.PageRequest{Key: nextKey},
+		}
+		balResp := banktypes.QueryAllBalancesResponse{}
+		err = client.Query(ctx, "/cosmos.bank.v1beta1.Query/AllBalances", &q, &balResp)
 		if err != nil {
-			err = fmt.Errorf("Could not unmarshal QueryBalanceResponse: %w", err)
 			return
 		}
-		if balResp.Balance != nil {
-			balances = balResp.Balance.String()
-			hasBalance = true
+		balances = append(balances, balResp.Balances...)
+		if balResp.Pagination == nil || len(balResp.Pagination.NextKey) == 0 {
+			break
 		}
+		nextKey = balResp.Pagination.NextKey
 	}
+	balances = balances.Sort()
+	hasBalance = !balances.IsZero()
 
 	return
 }
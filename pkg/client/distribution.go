@@ -0,0 +1,21 @@
+package client
+
+import (
+	"context"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+)
+
+// QueryDelegationRewards returns the outstanding staking rewards of delegator, per validator and in total
+func QueryDelegationRewards(ctx context.Context, client Transport, delegator string) (rewards []distrtypes.DelegationDelegatorReward, total sdk.DecCoins, err error) {
+	resp := distrtypes.QueryDelegationTotalRewardsResponse{}
+	err = client.Query(ctx, "/cosmos.distribution.v1beta1.Query/DelegationTotalRewards", &distrtypes.QueryDelegationTotalRewardsRequest{DelegatorAddress: delegator}, &resp)
+	return resp.Rewards, resp.Total, err
+}
+
+// QueryValidatorCommission returns the commission a validator has accumulated but not yet withdrawn
+func QueryValidatorCommission(ctx context.Context, client Transport, valoper string) (sdk.DecCoins, error) {
+	resp := distrtypes.QueryValidatorCommissionResponse{}
+	err := client.Query(ctx, "/cosmos.distribution.v1beta1.Query/ValidatorCommission", &distrtypes.QueryValidatorCommissionRequest{ValidatorAddress: valoper}, &resp)
+	return resp.Commission.Commission, err
+}
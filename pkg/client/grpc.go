@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	"github.com/johnsaigle/findaccount/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCTransport sends queries to the gRPC server of a node, usually listening on port 9090
+type GRPCTransport struct {
+	conn    *grpc.ClientConn
+	address string
+}
+
+// gogoCodec marshals the gogoproto generated types, which the default gRPC codec does not know about. It
+// goes by the name of the default codec so requests carry the usual application/grpc+proto content type.
+type gogoCodec struct{}
+
+func (gogoCodec) Marshal(v interface{}) ([]byte, error) {
+	return v.(ProtoMessage).Marshal()
+}
+
+func (gogoCodec) Unmarshal(data []byte, v interface{}) error {
+	return v.(ProtoMessage).Unmarshal(data)
+}
+
+func (gogoCodec) Name() string {
+	return "proto"
+}
+
+// NewGRPCTransport connects to a gRPC endpoint and checks it is in sync. The address may carry a scheme;
+// TLS is used for https:// addresses and port 443, plain text otherwise.
+func NewGRPCTransport(ctx context.Context, address string) (*GRPCTransport, error) {
+	target, secure, err := grpcTarget(address)
+	if err != nil {
+		return nil, err
+	}
+	creds := insecure.NewCredentials()
+	if secure {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	conn, err := grpc.DialContext(ctx, target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("Could not dial %s: %w", address, err)
+	}
+	t := &GRPCTransport{conn: conn, address: address}
+	err = t.Check(ctx)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// grpcTarget turns a chain-registry gRPC address into a host:port dial target
+func grpcTarget(address string) (target string, secure bool, err error) {
+	target = strings.TrimRight(address, "/")
+	switch scheme := protoRex.FindString(target); scheme {
+	case "https://":
+		secure = true
+	case "", "http://", "grpc://", "tcp://":
+	default:
+		return "", false, fmt.Errorf("Unknown protocol %s", scheme)
+	}
+	target = protoRex.ReplaceAllString(target, "")
+	if _, port, e := net.SplitHostPort(target); e == nil {
+		return target, secure || port == "443", nil
+	}
+	if secure {
+		return target + ":443", true, nil
+	}
+	return target + ":9090", false, nil
+}
+
+// NewGRPCTransportFromChainInfo connects to every gRPC endpoint of the chain at once and returns the one
+// that answered first
+func NewGRPCTransportFromChainInfo(ctx context.Context, grpcs []types.Grpc, chain string) (*GRPCTransport, error) {
+	addresses := make([]string, len(grpcs))
+	for i, endpoint := range grpcs {
+		addresses[i] = endpoint.Address
+	}
+	t, err := firstTransport(ctx, chain, "gRPC", addresses, func(ctx context.Context, address string) (Transport, error) {
+		return NewGRPCTransport(ctx, address)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t.(*GRPCTransport), nil
+}
+
+func (t *GRPCTransport) Name() string {
+	return TransportGRPC
+}
+
+func (t *GRPCTransport) Endpoint() string {
+	return t.address
+}
+
+// Close releases the connection
+func (t *GRPCTransport) Close() error {
+	return t.conn.Close()
+}
+
+func (t *GRPCTransport) Check(ctx context.Context) error {
+	resp := tmservice.GetSyncingResponse{}
+	err := t.Query(ctx, "/cosmos.base.tendermint.v1beta1.Service/GetSyncing", &tmservice.GetSyncingRequest{}, &resp)
+	if err != nil {
+		return err
+	}
+	if resp.Syncing {
+		return errors.New("node is catching up")
+	}
+	return nil
+}
+
+// Query invokes the gRPC method path with req and unmarshals the reply into resp
+func (t *GRPCTransport) Query(ctx context.Context, path string, req, resp ProtoMessage) error {
+	err := t.conn.Invoke(ctx, path, req, resp, grpc.ForceCodec(gogoCodec{}))
+	switch status.Code(err) {
+	case codes.OK:
+		return nil
+	case codes.NotFound:
+		return fmt.Errorf("%s: %w", path, ErrNotFound)
+	case codes.Unimplemented:
+		return fmt.Errorf("%s: %w", path, ErrUnknownQuery)
+	default:
+		return fmt.Errorf("%s query failed: %w", path, err)
+	}
+}
@@ -0,0 +1,295 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johnsaigle/findaccount/types"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+)
+
+// probeTimeout is the RPC timeout, in seconds, of a single health probe
+const probeTimeout = 5
+
+// Scoring weights. A score is roughly the latency in milliseconds an endpoint is worth, so one block of
+// lag costs as much as 250ms of latency. Lower scores are better.
+const (
+	lagPenalty      = 250
+	stalePenalty    = 10 // per second the latest block is older than staleAfter
+	staleAfter      = time.Minute
+	errorRateFactor = 4
+	// maxHistory bounds the probe history so that an endpoint that recovers is not held back forever
+	maxHistory = 100
+)
+
+// HealthFile is where endpoint rankings are kept between runs. It is set to a file in the user cache
+// directory at startup; an empty path disables persistence.
+var HealthFile = defaultHealthFile()
+
+// EndpointHealth is the result of probing one RPC endpoint, together with its probe history
+type EndpointHealth struct {
+	Address    string        `json:"address"`
+	Healthy    bool          `json:"healthy"`
+	Latency    time.Duration `json:"latency"`
+	Height     int64         `json:"height"`
+	BlockTime  time.Time     `json:"block_time"`
+	CatchingUp bool          `json:"catching_up"`
+	// Lag is the number of blocks the endpoint is behind the highest endpoint of the chain
+	Lag        int64     `json:"lag"`
+	Probes     int       `json:"probes"`
+	Failures   int       `json:"failures"`
+	// Score is only set for healthy endpoints, see scoreEndpoints
+	Score      float64   `json:"score"`
+	Error      string    `json:"error,omitempty"`
+	LastProbed time.Time `json:"last_probed"`
+}
+
+// ErrorRate is the share of probes of the endpoint that failed
+func (h EndpointHealth) ErrorRate() float64 {
+	if h.Probes == 0 {
+		return 0
+	}
+	return float64(h.Failures) / float64(h.Probes)
+}
+
+var (
+	healthMux    sync.Mutex
+	healthLoaded bool
+	healthCache  = make(map[string][]EndpointHealth)
+	// healthDirty is set when healthCache holds probes that are not in HealthFile yet
+	healthDirty bool
+	// saveMux orders writes of HealthFile so that a stale snapshot never replaces a newer one
+	saveMux sync.Mutex
+)
+
+func defaultHealthFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "findaccount", "endpoints.json")
+}
+
+// normalizeAddress adds the default port for the protocol of an RPC address that has none
+func normalizeAddress(address string) (string, error) {
+	address = strings.TrimRight(address, "/")
+	if portRex.MatchString(address) {
+		return address, nil
+	}
+	switch protoRex.FindString(address) {
+	case "https://":
+		return address + ":443", nil
+	case "http://":
+		return address + ":80", nil
+	case "tcp://":
+		return address + ":26657", nil
+	default:
+		return "", errors.New("Unknown protocol")
+	}
+}
+
+// probeEndpoint asks a single endpoint for its status and times the reply
+func probeEndpoint(ctx context.Context, address string) (health EndpointHealth, client *rpchttp.HTTP) {
+	health = EndpointHealth{Address: address, LastProbed: time.Now().UTC()}
+	normalized, err := normalizeAddress(address)
+	if err != nil {
+		health.Error = err.Error()
+		return
+	}
+	client, err = rpchttp.NewWithTimeout(normalized, "/websocket", probeTimeout)
+	if err != nil {
+		health.Error = err.Error()
+		return
+	}
+	start := time.Now()
+	status, err := client.Status(ctx)
+	health.Latency = time.Since(start)
+	if err != nil {
+		health.Error = err.Error()
+		return health, nil
+	}
+	health.Height = status.SyncInfo.LatestBlockHeight
+	health.BlockTime = status.SyncInfo.LatestBlockTime
+	health.CatchingUp = status.SyncInfo.CatchingUp
+	health.Healthy = !health.CatchingUp
+	if health.CatchingUp {
+		health.Error = "node is catching up"
+	}
+	return
+}
+
+// ProbeChain probes every RPC endpoint of the chain concurrently and returns them ranked best first,
+// along with a client for each healthy endpoint keyed by address. The probe history of every endpoint is
+// updated, unless ctx ended before the probes did, and persisted by the next SaveHealth.
+func ProbeChain(ctx context.Context, chain string, rpcs []types.Rpc) ([]EndpointHealth, map[string]*rpchttp.HTTP) {
+	ranking := make([]EndpointHealth, len(rpcs))
+	clients := make(map[string]*rpchttp.HTTP)
+	var mux sync.Mutex
+	wg := &sync.WaitGroup{}
+	wg.Add(len(rpcs))
+	for i := range rpcs {
+		i := i
+		go func() {
+			defer wg.Done()
+			health, client := probeEndpoint(ctx, rpcs[i].Address)
+			ranking[i] = health
+			if health.Healthy {
+				mux.Lock()
+				clients[health.Address] = client
+				mux.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		scoreEndpoints(ranking)
+		return ranking, clients
+	}
+
+	history := EndpointRanking(chain)
+	for i := range ranking {
+		for _, prev := range history {
+			if prev.Address == ranking[i].Address {
+				ranking[i].Probes, ranking[i].Failures = prev.Probes, prev.Failures
+				break
+			}
+		}
+		ranking[i].Probes++
+		if !ranking[i].Healthy {
+			ranking[i].Failures++
+		}
+		if ranking[i].Probes > maxHistory {
+			ranking[i].Probes /= 2
+			ranking[i].Failures /= 2
+		}
+	}
+	scoreEndpoints(ranking)
+
+	healthMux.Lock()
+	healthCache[chain] = ranking
+	healthDirty = true
+	healthMux.Unlock()
+	return ranking, clients
+}
+
+// scoreEndpoints scores the endpoints of a single chain against each other and sorts them best first.
+// Unhealthy endpoints always rank below healthy ones and are left unscored.
+func scoreEndpoints(ranking []EndpointHealth) {
+	var maxHeight int64
+	for _, h := range ranking {
+		if h.Healthy && h.Height > maxHeight {
+			maxHeight = h.Height
+		}
+	}
+	for i := range ranking {
+		h := &ranking[i]
+		if !h.Healthy {
+			h.Lag = 0
+			h.Score = 0
+			continue
+		}
+		h.Lag = maxHeight - h.Height
+		score := float64(h.Latency.Milliseconds()) + float64(h.Lag)*lagPenalty
+		if age := h.LastProbed.Sub(h.BlockTime); age > staleAfter {
+			score += age.Seconds() * stalePenalty
+		}
+		h.Score = score * (1 + errorRateFactor*h.ErrorRate())
+	}
+	sort.SliceStable(ranking, func(i, j int) bool {
+		if ranking[i].Healthy != ranking[j].Healthy {
+			return ranking[i].Healthy
+		}
+		if !ranking[i].Healthy {
+			return ranking[i].ErrorRate() < ranking[j].ErrorRate()
+		}
+		return ranking[i].Score < ranking[j].Score
+	})
+}
+
+// EndpointRanking returns the last known ranking of the endpoints of chain, which may come from an
+// earlier run
+func EndpointRanking(chain string) []EndpointHealth {
+	healthMux.Lock()
+	defer healthMux.Unlock()
+	loadHealth()
+	return append([]EndpointHealth(nil), healthCache[chain]...)
+}
+
+// loadHealth reads HealthFile the first time it is needed. The caller must hold healthMux.
+func loadHealth() {
+	if healthLoaded {
+		return
+	}
+	healthLoaded = true
+	if HealthFile == "" {
+		return
+	}
+	b, err := os.ReadFile(HealthFile)
+	if err != nil {
+		return
+	}
+	saved := make(map[string][]EndpointHealth)
+	if json.Unmarshal(b, &saved) != nil {
+		return
+	}
+	for chain, ranking := range saved {
+		if _, ok := healthCache[chain]; !ok {
+			healthCache[chain] = ranking
+		}
+	}
+}
+
+// SaveHealth writes the rankings of every chain to HealthFile, replacing it atomically. It does nothing
+// when no chain was probed since the last save, so it is called once a search or an endpoints run is done
+// rather than after every probe.
+func SaveHealth() (err error) {
+	if HealthFile == "" {
+		return nil
+	}
+	saveMux.Lock()
+	defer saveMux.Unlock()
+	healthMux.Lock()
+	if !healthDirty {
+		healthMux.Unlock()
+		return nil
+	}
+	loadHealth()
+	b, err := json.MarshalIndent(healthCache, "", "  ")
+	healthDirty = false
+	healthMux.Unlock()
+	defer func() {
+		if err != nil {
+			// the probes are still worth saving next time
+			healthMux.Lock()
+			healthDirty = true
+			healthMux.Unlock()
+		}
+	}()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(HealthFile), 0o755)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(HealthFile), "endpoints-*.json")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), HealthFile)
+}
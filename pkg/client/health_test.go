@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/johnsaigle/findaccount/types"
+)
+
+func TestProbesAreSavedOnce(t *testing.T) {
+	saved := HealthFile
+	t.Cleanup(func() { HealthFile = saved })
+	HealthFile = filepath.Join(t.TempDir(), "endpoints.json")
+
+	rpcs := []types.Rpc{{Address: "http://127.0.0.1:1"}, {Address: "http://127.0.0.1:2"}}
+	for i := 0; i < 2; i++ {
+		ranking, _ := ProbeChain(context.Background(), "cosmoshub", rpcs)
+		for _, h := range ranking {
+			if h.Healthy || h.Score != 0 {
+				t.Errorf("got %+v for an endpoint that is down, want it unhealthy and unscored", h)
+			}
+		}
+	}
+	if _, err := os.Stat(HealthFile); !os.IsNotExist(err) {
+		t.Fatalf("the probes were saved before SaveHealth: %v", err)
+	}
+
+	if err := SaveHealth(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(HealthFile); err != nil {
+		t.Fatalf("the probes were not saved: %v", err)
+	}
+	if err := os.Remove(HealthFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveHealth(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(HealthFile); !os.IsNotExist(err) {
+		t.Errorf("the probes were saved again without new ones: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+)
+
+// Denom traces never change for a given hash on a given chain, so they are cached for the life of the process
+var (
+	traceMux   sync.Mutex
+	traceCache = make(map[string]map[string]transfertypes.DenomTrace)
+)
+
+// QueryDenomTrace asks the chain which path and base denom an ibc/ denom hash was derived from
+func QueryDenomTrace(ctx context.Context, client Transport, denom string) (trace transfertypes.DenomTrace, err error) {
+	resp := transfertypes.QueryDenomTraceResponse{}
+	err = client.Query(ctx, "/ibc.applications.transfer.v1.Query/DenomTrace", &transfertypes.QueryDenomTraceRequest{Hash: strings.TrimPrefix(denom, "ibc/")}, &resp)
+	if err != nil {
+		return
+	}
+	if resp.DenomTrace == nil {
+		err = fmt.Errorf("no denom trace found for %s", denom)
+		return
+	}
+	trace = *resp.DenomTrace
+	return
+}
+
+// ResolveDenomTraces returns the denom traces for every ibc/ denom in coins, keyed by denom. Results are
+// cached per chain. Denoms that cannot be resolved are left out of the map so the caller can fall back
+// to other metadata.
+func ResolveDenomTraces(ctx context.Context, client Transport, chain string, coins sdk.Coins) map[string]transfertypes.DenomTrace {
+	traces := make(map[string]transfertypes.DenomTrace)
+	for _, c := range coins {
+		if !strings.HasPrefix(c.Denom, "ibc/") {
+			continue
+		}
+
+		traceMux.Lock()
+		trace, ok := traceCache[chain][c.Denom]
+		traceMux.Unlock()
+		if ok {
+			traces[c.Denom] = trace
+			continue
+		}
+
+		trace, err := QueryDenomTrace(ctx, client, c.Denom)
+		if err != nil {
+			continue
+		}
+		traceMux.Lock()
+		if traceCache[chain] == nil {
+			traceCache[chain] = make(map[string]transfertypes.DenomTrace)
+		}
+		traceCache[chain][c.Denom] = trace
+		traceMux.Unlock()
+		traces[c.Denom] = trace
+	}
+	return traces
+}
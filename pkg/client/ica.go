@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+	icacontrollertypes "github.com/cosmos/ibc-go/v7/modules/apps/27-interchain-accounts/controller/types"
+	connectiontypes "github.com/cosmos/ibc-go/v7/modules/core/03-connection/types"
+)
+
+// QueryControllerEnabled reports whether the chain runs the interchain accounts controller module. Chains
+// without the module at all are reported as disabled. Over REST a missing module shows up as a route that
+// is not found.
+func QueryControllerEnabled(ctx context.Context, client Transport) (bool, error) {
+	resp := icacontrollertypes.QueryParamsResponse{}
+	err := client.Query(ctx, "/ibc.applications.interchain_accounts.controller.v1.Query/Params", &icacontrollertypes.QueryParamsRequest{}, &resp)
+	if errors.Is(err, ErrUnknownQuery) || errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil || resp.Params == nil {
+		return false, err
+	}
+	return resp.Params.ControllerEnabled, nil
+}
+
+// QueryOpenConnections returns every IBC connection of the chain that is in the OPEN state
+func QueryOpenConnections(ctx context.Context, client Transport) (connections []connectiontypes.IdentifiedConnection, err error) {
+	var nextKey []byte
+	for {
+		req := connectiontypes.QueryConnectionsRequest{
+			Pagination: &querytypes.PageRequest{Key: nextKey},
+		}
+		resp := connectiontypes.QueryConnectionsResponse{}
+		err = client.Query(ctx, "/ibc.core.connection.v1.Query/Connections", &req, &resp)
+		if err != nil {
+			return
+		}
+		for _, c := range resp.Connections {
+			if c != nil && c.State == connectiontypes.OPEN {
+				connections = append(connections, *c)
+			}
+		}
+		if resp.Pagination == nil || len(resp.Pagination.NextKey) == 0 {
+			return
+		}
+		nextKey = resp.Pagination.NextKey
+	}
+}
+
+// QueryInterchainAccount returns the address of the interchain account owned by owner on the host chain
+// at the other end of connectionId. ErrNotFound is returned when owner has not registered one.
+func QueryInterchainAccount(ctx context.Context, client Transport, owner, connectionId string) (string, error) {
+	resp := icacontrollertypes.QueryInterchainAccountResponse{}
+	err := client.Query(ctx, "/ibc.applications.interchain_accounts.controller.v1.Query/InterchainAccount", &icacontrollertypes.QueryInterchainAccountRequest{Owner: owner, ConnectionId: connectionId}, &resp)
+	return resp.Address, err
+}
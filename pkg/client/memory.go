@@ -0,0 +1,206 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	staketypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	connectiontypes "github.com/cosmos/ibc-go/v7/modules/core/03-connection/types"
+)
+
+// TransportMemory names the MemQuerier in results
+const TransportMemory = "memory"
+
+// MemQuerier is a ChainQuerier that answers from the state it holds, so searches can run without network
+// access. Maps are keyed by bech32 address unless noted otherwise; anything missing from them is reported
+// the way a node reports it, usually as ErrNotFound. A MemQuerier must not be modified while it is in use.
+type MemQuerier struct {
+	// Denom is the bond denom of the chain
+	Denom string
+	// Coins are the balances of each address
+	Coins map[string]sdk.Coins
+	// Traces are keyed by ibc/ denom
+	Traces map[string]transfertypes.DenomTrace
+	// Accounts are the x/auth accounts of each address
+	Accounts map[string]authtypes.AccountI
+	// Validators are keyed by operator address
+	Validators map[string]staketypes.Validator
+	// DelegationsByDelegator, Unbondings and RedelegationsByDelegator are keyed by delegator address
+	DelegationsByDelegator   map[string][]staketypes.DelegationResponse
+	Unbondings               map[string][]staketypes.UnbondingDelegation
+	RedelegationsByDelegator map[string][]staketypes.RedelegationResponse
+	// Rewards are the outstanding rewards of each delegator; the total is summed from them
+	Rewards map[string][]distrtypes.DelegationDelegatorReward
+	// Commissions are keyed by operator address
+	Commissions map[string]sdk.DecCoins
+	// SigningInfos are keyed by consensus address
+	SigningInfos map[string]slashingtypes.ValidatorSigningInfo
+	Slashing     slashingtypes.Params
+	// ICAEnabled reports whether the chain runs the interchain accounts controller module
+	ICAEnabled  bool
+	Connections []connectiontypes.IdentifiedConnection
+	// ICAs are keyed by owner and connection id joined by a slash, e.g. "cosmos1.../connection-0"
+	ICAs map[string]string
+}
+
+func (m *MemQuerier) Transport() string {
+	return TransportMemory
+}
+
+func (m *MemQuerier) Endpoint() string {
+	return TransportMemory
+}
+
+func (m *MemQuerier) Balances(ctx context.Context, address string) (sdk.Coins, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.Coins[address].Sort(), nil
+}
+
+func (m *MemQuerier) DenomTraces(ctx context.Context, coins sdk.Coins) map[string]transfertypes.DenomTrace {
+	traces := make(map[string]transfertypes.DenomTrace)
+	for _, c := range coins {
+		if trace, ok := m.Traces[c.Denom]; ok && strings.HasPrefix(c.Denom, "ibc/") {
+			traces[c.Denom] = trace
+		}
+	}
+	return traces
+}
+
+func (m *MemQuerier) AuthAccount(ctx context.Context, address string) (*codectypes.Any, authtypes.AccountI, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	account, ok := m.Accounts[address]
+	if !ok {
+		return nil, nil, fmt.Errorf("account %s: %w", address, ErrNotFound)
+	}
+	raw, err := codectypes.NewAnyWithValue(account)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Could not pack account: %w", err)
+	}
+	return raw, account, nil
+}
+
+func (m *MemQuerier) BondDenom(ctx context.Context) (string, error) {
+	return m.Denom, ctx.Err()
+}
+
+func (m *MemQuerier) Validator(ctx context.Context, valoper string) (staketypes.Validator, error) {
+	if err := ctx.Err(); err != nil {
+		return staketypes.Validator{}, err
+	}
+	val, ok := m.Validators[valoper]
+	if !ok {
+		return val, fmt.Errorf("validator %s: %w", valoper, ErrNotFound)
+	}
+	err := val.UnpackInterfaces(interfaceRegistry)
+	if err != nil {
+		return val, fmt.Errorf("Could not unpack consensus pubkey: %w", err)
+	}
+	return val, nil
+}
+
+func (m *MemQuerier) BondedValidators(ctx context.Context) ([]staketypes.Validator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var validators []staketypes.Validator
+	for _, val := range m.Validators {
+		if val.Status == staketypes.Bonded {
+			validators = append(validators, val)
+		}
+	}
+	return validators, nil
+}
+
+func (m *MemQuerier) Delegation(ctx context.Context, delegator, valoper string) (staketypes.DelegationResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return staketypes.DelegationResponse{}, err
+	}
+	for _, d := range m.DelegationsByDelegator[delegator] {
+		if d.Delegation.ValidatorAddress == valoper {
+			return d, nil
+		}
+	}
+	return staketypes.DelegationResponse{}, fmt.Errorf("delegation %s to %s: %w", delegator, valoper, ErrNotFound)
+}
+
+func (m *MemQuerier) Delegations(ctx context.Context, delegator string) ([]staketypes.DelegationResponse, error) {
+	return m.DelegationsByDelegator[delegator], ctx.Err()
+}
+
+func (m *MemQuerier) UnbondingDelegations(ctx context.Context, delegator string) ([]staketypes.UnbondingDelegation, error) {
+	return m.Unbondings[delegator], ctx.Err()
+}
+
+func (m *MemQuerier) Redelegations(ctx context.Context, delegator string) ([]staketypes.RedelegationResponse, error) {
+	return m.RedelegationsByDelegator[delegator], ctx.Err()
+}
+
+func (m *MemQuerier) DelegationRewards(ctx context.Context, delegator string) ([]distrtypes.DelegationDelegatorReward, sdk.DecCoins, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	rewards := m.Rewards[delegator]
+	var total sdk.DecCoins
+	for _, r := range rewards {
+		total = total.Add(r.Reward...)
+	}
+	return rewards, total, nil
+}
+
+func (m *MemQuerier) ValidatorCommission(ctx context.Context, valoper string) (sdk.DecCoins, error) {
+	return m.Commissions[valoper], ctx.Err()
+}
+
+func (m *MemQuerier) SigningInfo(ctx context.Context, consAddress string) (slashingtypes.ValidatorSigningInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return slashingtypes.ValidatorSigningInfo{}, err
+	}
+	info, ok := m.SigningInfos[consAddress]
+	if !ok {
+		return info, fmt.Errorf("signing info %s: %w", consAddress, ErrNotFound)
+	}
+	return info, nil
+}
+
+func (m *MemQuerier) SlashingParams(ctx context.Context) (slashingtypes.Params, error) {
+	return m.Slashing, ctx.Err()
+}
+
+func (m *MemQuerier) ControllerEnabled(ctx context.Context) (bool, error) {
+	return m.ICAEnabled, ctx.Err()
+}
+
+func (m *MemQuerier) OpenConnections(ctx context.Context) ([]connectiontypes.IdentifiedConnection, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var connections []connectiontypes.IdentifiedConnection
+	for _, c := range m.Connections {
+		if c.State == connectiontypes.OPEN {
+			connections = append(connections, c)
+		}
+	}
+	return connections, nil
+}
+
+func (m *MemQuerier) InterchainAccount(ctx context.Context, owner, connectionId string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	addr, ok := m.ICAs[owner+"/"+connectionId]
+	if !ok {
+		return "", fmt.Errorf("interchain account of %s on %s: %w", owner, connectionId, ErrNotFound)
+	}
+	return addr, nil
+}
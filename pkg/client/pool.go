@@ -0,0 +1,248 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/johnsaigle/findaccount/types"
+)
+
+// DefaultRevalidateInterval is how often a Pool checks its clients are still healthy
+const DefaultRevalidateInterval = time.Minute
+
+// revalidateTimeout bounds the background check of a single chain
+const revalidateTimeout = 30 * time.Second
+
+// Pool keeps one healthy client per chain for the life of the process so that searches do not pay for a
+// Status round-trip on every chain each time. RPC endpoints are preferred; when none of them is healthy
+// the gRPC endpoints of the chain are used instead, and failing those its REST endpoints. Clients are
+// re-validated in the background and replaced when their endpoint stops answering or falls behind, and
+// gRPC and REST clients are swapped back for an RPC client once one is available again. A chain that
+// could not be reached is not retried until the next validation round, so a dead chain only costs one
+// timeout per interval.
+type Pool struct {
+	interval time.Duration
+
+	mux    sync.Mutex
+	chains map[string]*pooledChain
+
+	start sync.Once
+	stop  chan struct{}
+}
+
+type pooledChain struct {
+	chain string
+	info  *types.ChainInfo
+
+	mux     sync.Mutex
+	client  Transport
+	err     error
+	checked time.Time
+}
+
+// NewPool returns an empty pool re-validating its clients every interval. The background loop starts
+// with the first client handed out.
+func NewPool(interval time.Duration) *Pool {
+	if interval <= 0 {
+		interval = DefaultRevalidateInterval
+	}
+	return &Pool{
+		interval: interval,
+		chains:   make(map[string]*pooledChain),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Client returns the pooled client for chain, connecting to one of its endpoints if there is none yet. Concurrent
+// callers asking for the same chain share a single connection attempt, which is bounded by ctx of the
+// caller that started it.
+func (p *Pool) Client(ctx context.Context, chain string, info *types.ChainInfo) (Transport, error) {
+	p.start.Do(func() { go p.revalidate() })
+
+	p.mux.Lock()
+	pc, ok := p.chains[chain]
+	if !ok {
+		pc = &pooledChain{chain: chain, info: info}
+		p.chains[chain] = pc
+	}
+	p.mux.Unlock()
+
+	pc.mux.Lock()
+	defer pc.mux.Unlock()
+	if pc.client != nil {
+		return pc.client, nil
+	}
+	if pc.err != nil && time.Since(pc.checked) < p.interval {
+		return nil, pc.err
+	}
+	pc.connect(ctx)
+	if pc.client == nil && ctx.Err() != nil {
+		// the caller gave up, which says nothing about the chain, so the next caller tries again
+		err := pc.err
+		pc.err = nil
+		return nil, err
+	}
+	return pc.client, pc.err
+}
+
+// Close stops the background validation. Clients already handed out keep working.
+func (p *Pool) Close() {
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+}
+
+// revalidate checks every pooled client once per interval until the pool is closed
+func (p *Pool) revalidate() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		p.mux.Lock()
+		chains := make([]*pooledChain, 0, len(p.chains))
+		for _, pc := range p.chains {
+			chains = append(chains, pc)
+		}
+		p.mux.Unlock()
+
+		wg := &sync.WaitGroup{}
+		wg.Add(len(chains))
+		for _, pc := range chains {
+			pc := pc
+			go func() {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(context.Background(), revalidateTimeout)
+				defer cancel()
+				pc.revalidate(ctx)
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+// revalidate checks the client of the chain and replaces it when it is unhealthy, or when it is not an RPC
+// client and an RPC endpoint is healthy again. Endpoints are probed without holding pc.mux so searches keep
+// using the current client in the meantime.
+func (pc *pooledChain) revalidate(ctx context.Context) {
+	pc.mux.Lock()
+	current := pc.client
+	pc.mux.Unlock()
+
+	if current == nil || current.Check(ctx) != nil {
+		client, err := dial(ctx, pc.chain, pc.info)
+		pc.swap(current, client, err)
+		return
+	}
+	if current.Name() == TransportRPC {
+		pc.swap(current, current, nil)
+		return
+	}
+	client, err := NewClientFromChainInfo(ctx, pc.info.Apis.Rpc, pc.chain)
+	if err != nil {
+		pc.swap(current, current, nil)
+		return
+	}
+	pc.swap(current, RPCTransport{Client: client}, nil)
+}
+
+// swap replaces the client of the chain with client, or records err when there is none, as long as the
+// client is still old. When another caller replaced it in the meantime, client is discarded.
+func (pc *pooledChain) swap(old, client Transport, err error) {
+	pc.mux.Lock()
+	defer pc.mux.Unlock()
+	if pc.client != old {
+		if client != old {
+			closeTransport(client)
+		}
+		return
+	}
+	if client != old {
+		closeTransport(old)
+	}
+	pc.client, pc.err, pc.checked = client, err, time.Now()
+}
+
+// connect replaces the client of the chain with a fresh one. The caller must hold pc.mux.
+func (pc *pooledChain) connect(ctx context.Context) {
+	closeTransport(pc.client)
+	pc.client = nil
+	pc.checked = time.Now()
+	pc.client, pc.err = dial(ctx, pc.chain, pc.info)
+}
+
+// dial connects to the best healthy endpoint of the chain, over gRPC or REST if no RPC endpoint is healthy
+func dial(ctx context.Context, chain string, info *types.ChainInfo) (Transport, error) {
+	client, err := NewClientFromChainInfo(ctx, info.Apis.Rpc, chain)
+	if err == nil {
+		return RPCTransport{Client: client}, nil
+	}
+	grpcClient, grpcErr := NewGRPCTransportFromChainInfo(ctx, info.Apis.Grpc, chain)
+	if grpcErr == nil {
+		return grpcClient, nil
+	}
+	restClient, restErr := NewRESTTransportFromChainInfo(ctx, info.Apis.Rest, chain)
+	if restErr == nil {
+		return restClient, nil
+	}
+	return nil, fmt.Errorf("%w; %s; %s", err, grpcErr, restErr)
+}
+
+// closeTransport releases the connection of transports that hold one open
+func closeTransport(t Transport) {
+	if closer, ok := t.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// firstTransport connects to every address of the chain at once and returns the transport that answered
+// first; the others are closed. kind names the endpoints in errors, e.g. gRPC.
+func firstTransport(ctx context.Context, chain, kind string, addresses []string, connect func(context.Context, string) (Transport, error)) (Transport, error) {
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("could not connect to any %s endpoints for %s: no %s endpoints known", kind, chain, kind)
+	}
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout*time.Second)
+	defer cancel()
+
+	var (
+		mux     sync.Mutex
+		best    Transport
+		lastErr error
+	)
+	wg := &sync.WaitGroup{}
+	wg.Add(len(addresses))
+	for _, address := range addresses {
+		address := address
+		go func() {
+			defer wg.Done()
+			t, err := connect(ctx, address)
+			mux.Lock()
+			defer mux.Unlock()
+			switch {
+			case err != nil:
+				lastErr = err
+			case best == nil:
+				best = t
+				// the others are no longer needed
+				cancel()
+			default:
+				closeTransport(t)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if best == nil {
+		return nil, fmt.Errorf("could not connect to any %s endpoints for %s: %w", kind, chain, lastErr)
+	}
+	return best, nil
+}
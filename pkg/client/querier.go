@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	staketypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	connectiontypes "github.com/cosmos/ibc-go/v7/modules/core/03-connection/types"
+)
+
+// ChainQuerier answers the questions a search asks of a single chain. TransportQuerier implements it over
+// the RPC, gRPC and REST transports, and MemQuerier from data held in memory. Lookups of objects the
+// chain does not know about return ErrNotFound.
+type ChainQuerier interface {
+	// Transport and Endpoint describe how the chain is reached, see Transport
+	Transport() string
+	Endpoint() string
+
+	// Balances returns every coin held by address, sorted by denom
+	Balances(ctx context.Context, address string) (sdk.Coins, error)
+	// DenomTraces resolves the ibc/ denoms in coins, leaving out those that cannot be resolved
+	DenomTraces(ctx context.Context, coins sdk.Coins) map[string]transfertypes.DenomTrace
+	// AuthAccount returns the x/auth account of address. account is nil when the account type of raw
+	// cannot be decoded.
+	AuthAccount(ctx context.Context, address string) (raw *codectypes.Any, account authtypes.AccountI, err error)
+
+	BondDenom(ctx context.Context) (string, error)
+	// Validator returns the validator with operator address valoper, with its consensus pubkey unpacked
+	Validator(ctx context.Context, valoper string) (staketypes.Validator, error)
+	BondedValidators(ctx context.Context) ([]staketypes.Validator, error)
+	Delegation(ctx context.Context, delegator, valoper string) (staketypes.DelegationResponse, error)
+	Delegations(ctx context.Context, delegator string) ([]staketypes.DelegationResponse, error)
+	UnbondingDelegations(ctx context.Context, delegator string) ([]staketypes.UnbondingDelegation, error)
+	Redelegations(ctx context.Context, delegator string) ([]staketypes.RedelegationResponse, error)
+
+	DelegationRewards(ctx context.Context, delegator string) ([]distrtypes.DelegationDelegatorReward, sdk.DecCoins, error)
+	ValidatorCommission(ctx context.Context, valoper string) (sdk.DecCoins, error)
+
+	SigningInfo(ctx context.Context, consAddress string) (slashingtypes.ValidatorSigningInfo, error)
+	SlashingParams(ctx context.Context) (slashingtypes.Params, error)
+
+	ControllerEnabled(ctx context.Context) (bool, error)
+	OpenConnections(ctx context.Context) ([]connectiontypes.IdentifiedConnection, error)
+	InterchainAccount(ctx context.Context, owner, connectionId string) (string, error)
+}
+
+// TransportQuerier answers queries for a chain through the query functions of this package. Chain names
+// the chain in the per-chain caches.
+type TransportQuerier struct {
+	Chain  string
+	Client Transport
+}
+
+// NewQuerier returns a ChainQuerier for chain sending its queries over client
+func NewQuerier(chain string, client Transport) *TransportQuerier {
+	return &TransportQuerier{Chain: chain, Client: client}
+}
+
+func (q *TransportQuerier) Transport() string {
+	return q.Client.Name()
+}
+
+func (q *TransportQuerier) Endpoint() string {
+	return q.Client.Endpoint()
+}
+
+func (q *TransportQuerier) Balances(ctx context.Context, address string) (sdk.Coins, error) {
+	_, balances, err := QueryAccount(ctx, q.Client, address)
+	return balances, err
+}
+
+func (q *TransportQuerier) DenomTraces(ctx context.Context, coins sdk.Coins) map[string]transfertypes.DenomTrace {
+	return ResolveDenomTraces(ctx, q.Client, q.Chain, coins)
+}
+
+func (q *TransportQuerier) AuthAccount(ctx context.Context, address string) (*codectypes.Any, authtypes.AccountI, error) {
+	return QueryAuthAccount(ctx, q.Client, address)
+}
+
+func (q *TransportQuerier) BondDenom(ctx context.Context) (string, error) {
+	return QueryBondDenom(ctx, q.Client, q.Chain)
+}
+
+func (q *TransportQuerier) Validator(ctx context.Context, valoper string) (staketypes.Validator, error) {
+	val, err := QueryValidator(ctx, q.Client, valoper)
+	if err != nil {
+		return val, err
+	}
+	if val.OperatorAddress == "" {
+		return val, fmt.Errorf("validator %s: %w", valoper, ErrNotFound)
+	}
+	err = val.UnpackInterfaces(interfaceRegistry)
+	if err != nil {
+		return val, fmt.Errorf("Could not unpack consensus pubkey: %w", err)
+	}
+	return val, nil
+}
+
+func (q *TransportQuerier) BondedValidators(ctx context.Context) ([]staketypes.Validator, error) {
+	return QueryBondedValidators(ctx, q.Client)
+}
+
+func (q *TransportQuerier) Delegation(ctx context.Context, delegator, valoper string) (staketypes.DelegationResponse, error) {
+	return QueryDelegation(ctx, q.Client, delegator, valoper)
+}
+
+func (q *TransportQuerier) Delegations(ctx context.Context, delegator string) ([]staketypes.DelegationResponse, error) {
+	return QueryDelegations(ctx, q.Client, delegator)
+}
+
+func (q *TransportQuerier) UnbondingDelegations(ctx context.Context, delegator string) ([]staketypes.UnbondingDelegation, error) {
+	return QueryUnbondingDelegations(ctx, q.Client, delegator)
+}
+
+func (q *TransportQuerier) Redelegations(ctx context.Context, delegator string) ([]staketypes.RedelegationResponse, error) {
+	return QueryRedelegations(ctx, q.Client, delegator)
+}
+
+func (q *TransportQuerier) DelegationRewards(ctx context.Context, delegator string) ([]distrtypes.DelegationDelegatorReward, sdk.DecCoins, error) {
+	return QueryDelegationRewards(ctx, q.Client, delegator)
+}
+
+func (q *TransportQuerier) ValidatorCommission(ctx context.Context, valoper string) (sdk.DecCoins, error) {
+	return QueryValidatorCommission(ctx, q.Client, valoper)
+}
+
+func (q *TransportQuerier) SigningInfo(ctx context.Context, consAddress string) (slashingtypes.ValidatorSigningInfo, error) {
+	return QuerySigningInfo(ctx, q.Client, consAddress)
+}
+
+func (q *TransportQuerier) SlashingParams(ctx context.Context) (slashingtypes.Params, error) {
+	return QuerySlashingParams(ctx, q.Client)
+}
+
+func (q *TransportQuerier) ControllerEnabled(ctx context.Context) (bool, error) {
+	return QueryControllerEnabled(ctx, q.Client)
+}
+
+func (q *TransportQuerier) OpenConnections(ctx context.Context) ([]connectiontypes.IdentifiedConnection, error) {
+	return QueryOpenConnections(ctx, q.Client)
+}
+
+func (q *TransportQuerier) InterchainAccount(ctx context.Context, owner, connectionId string) (string, error) {
+	return QueryInterchainAccount(ctx, q.Client, owner, connectionId)
+}
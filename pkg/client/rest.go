@@ -0,0 +1,247 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cosmos/gogoproto/jsonpb"
+	"github.com/cosmos/gogoproto/proto"
+	"github.com/johnsaigle/findaccount/types"
+	"google.golang.org/grpc/codes"
+)
+
+// restRoutes maps the gRPC query methods used by this package onto their grpc-gateway routes. Fields of
+// the request named in braces are substituted into the path; the others are sent as URL parameters.
+var restRoutes = map[string]string{
+	"/cosmos.auth.v1beta1.Query/Account":                                          "/cosmos/auth/v1beta1/accounts/{address}",
+	"/cosmos.bank.v1beta1.Query/AllBalances":                                      "/cosmos/bank/v1beta1/balances/{address}",
+	"/cosmos.base.tendermint.v1beta1.Service/GetSyncing":                          "/cosmos/base/tendermint/v1beta1/syncing",
+	"/cosmos.distribution.v1beta1.Query/DelegationTotalRewards":                   "/cosmos/distribution/v1beta1/delegators/{delegator_address}/rewards",
+	"/cosmos.distribution.v1beta1.Query/ValidatorCommission":                      "/cosmos/distribution/v1beta1/validators/{validator_address}/commission",
+	"/cosmos.slashing.v1beta1.Query/Params":                                       "/cosmos/slashing/v1beta1/params",
+	"/cosmos.slashing.v1beta1.Query/SigningInfo":                                  "/cosmos/slashing/v1beta1/signing_infos/{cons_address}",
+	"/cosmos.staking.v1beta1.Query/Delegation":                                    "/cosmos/staking/v1beta1/validators/{validator_addr}/delegations/{delegator_addr}",
+	"/cosmos.staking.v1beta1.Query/DelegatorDelegations":                          "/cosmos/staking/v1beta1/delegations/{delegator_addr}",
+	"/cosmos.staking.v1beta1.Query/DelegatorUnbondingDelegations":                 "/cosmos/staking/v1beta1/delegators/{delegator_addr}/unbonding_delegations",
+	"/cosmos.staking.v1beta1.Query/Params":                                        "/cosmos/staking/v1beta1/params",
+	"/cosmos.staking.v1beta1.Query/Redelegations":                                 "/cosmos/staking/v1beta1/delegators/{delegator_addr}/redelegations",
+	"/cosmos.staking.v1beta1.Query/Validator":                                     "/cosmos/staking/v1beta1/validators/{validator_addr}",
+	"/cosmos.staking.v1beta1.Query/Validators":                                    "/cosmos/staking/v1beta1/validators",
+	"/ibc.applications.interchain_accounts.controller.v1.Query/InterchainAccount": "/ibc/apps/interchain_accounts/controller/v1/owners/{owner}/connections/{connection_id}",
+	"/ibc.applications.interchain_accounts.controller.v1.Query/Params":            "/ibc/apps/interchain_accounts/controller/v1/params",
+	"/ibc.applications.transfer.v1.Query/DenomTrace":                              "/ibc/apps/transfer/v1/denom_traces/{hash}",
+	"/ibc.core.connection.v1.Query/Connections":                                   "/ibc/core/connection/v1/connections",
+}
+
+var routeParamRex = regexp.MustCompile(`\{(\w+)\}`)
+
+// RESTTransport sends queries to the REST (LCD) server of a node, which serves the gRPC query services
+// as JSON through grpc-gateway
+type RESTTransport struct {
+	address string
+	http    *http.Client
+}
+
+// NewRESTTransport checks the REST endpoint at address is in sync and returns a transport for it
+func NewRESTTransport(ctx context.Context, address string) (*RESTTransport, error) {
+	address = strings.TrimRight(address, "/")
+	switch protoRex.FindString(address) {
+	case "https://", "http://":
+	default:
+		return nil, errors.New("Unknown protocol")
+	}
+	t := &RESTTransport{address: address, http: &http.Client{Timeout: 10 * time.Second}}
+	err := t.Check(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// NewRESTTransportFromChainInfo checks every REST endpoint of the chain at once and returns the one that
+// answered first
+func NewRESTTransportFromChainInfo(ctx context.Context, rests []types.Rest, chain string) (*RESTTransport, error) {
+	addresses := make([]string, len(rests))
+	for i, endpoint := range rests {
+		addresses[i] = endpoint.Address
+	}
+	t, err := firstTransport(ctx, chain, "REST", addresses, func(ctx context.Context, address string) (Transport, error) {
+		return NewRESTTransport(ctx, address)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t.(*RESTTransport), nil
+}
+
+func (t *RESTTransport) Name() string {
+	return TransportREST
+}
+
+func (t *RESTTransport) Endpoint() string {
+	return t.address
+}
+
+func (t *RESTTransport) Check(ctx context.Context) error {
+	var resp struct {
+		Syncing bool `json:"syncing"`
+	}
+	err := t.get(ctx, "/cosmos/base/tendermint/v1beta1/syncing", func(body []byte) error {
+		return json.Unmarshal(body, &resp)
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Syncing {
+		return errors.New("node is catching up")
+	}
+	return nil
+}
+
+// Query sends req to the grpc-gateway route of the query method at path and decodes the JSON reply into
+// resp. Methods without a known route are reported as ErrUnknownQuery.
+func (t *RESTTransport) Query(ctx context.Context, path string, req, resp ProtoMessage) error {
+	route, ok := restRoutes[path]
+	if !ok {
+		return fmt.Errorf("%s has no REST route: %w", path, ErrUnknownQuery)
+	}
+	reqMsg, ok := req.(proto.Message)
+	respMsg, ok2 := resp.(proto.Message)
+	if !ok || !ok2 {
+		return fmt.Errorf("Could not encode request for %s as JSON", path)
+	}
+
+	// fields left at their default value are omitted, as the gateway would fill them in anyway
+	var reqJSON bytes.Buffer
+	err := (&jsonpb.Marshaler{OrigName: true}).Marshal(&reqJSON, reqMsg)
+	if err != nil {
+		return fmt.Errorf("Could not marshal request for %s: %w", path, err)
+	}
+	fields := make(map[string]interface{})
+	err = json.Unmarshal(reqJSON.Bytes(), &fields)
+	if err != nil {
+		return fmt.Errorf("Could not marshal request for %s: %w", path, err)
+	}
+
+	route = routeParamRex.ReplaceAllStringFunc(route, func(param string) string {
+		name := strings.Trim(param, "{}")
+		value := fmt.Sprint(fields[name])
+		delete(fields, name)
+		return url.PathEscape(value)
+	})
+	params := url.Values{}
+	flattenParams(params, "", fields)
+	if len(params) > 0 {
+		route += "?" + params.Encode()
+	}
+
+	err = t.get(ctx, route, func(body []byte) error {
+		unmarshaler := jsonpb.Unmarshaler{AnyResolver: opaqueResolver{}, AllowUnknownFields: true}
+		return unmarshaler.Unmarshal(bytes.NewReader(body), respMsg)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// opaqueResolver resolves the types known to interfaceRegistry. Any other type, such as the account types
+// of chains with their own modules, is kept as an opaque Any holding its type URL, so the rest of the
+// reply can still be decoded.
+type opaqueResolver struct{}
+
+func (opaqueResolver) Resolve(typeUrl string) (proto.Message, error) {
+	msg, err := interfaceRegistry.Resolve(typeUrl)
+	if err != nil {
+		return &opaqueMessage{}, nil
+	}
+	return msg, nil
+}
+
+// opaqueMessage keeps the JSON of a message of unknown type. The value of an Any holding one is that JSON,
+// not the protobuf encoding of the message, so it cannot be unpacked.
+type opaqueMessage struct {
+	json []byte
+}
+
+func (m *opaqueMessage) Reset()         { m.json = nil }
+func (m *opaqueMessage) String() string { return string(m.json) }
+func (m *opaqueMessage) ProtoMessage()  {}
+
+func (m *opaqueMessage) UnmarshalJSONPB(_ *jsonpb.Unmarshaler, b []byte) error {
+	m.json = append(m.json[:0], b...)
+	return nil
+}
+
+func (m *opaqueMessage) Marshal() ([]byte, error) {
+	return m.json, nil
+}
+
+// flattenParams turns the nested fields of a request into grpc-gateway URL parameters, e.g.
+// pagination.key
+func flattenParams(params url.Values, prefix string, fields map[string]interface{}) {
+	for name, value := range fields {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flattenParams(params, prefix+name+".", v)
+		case []interface{}:
+			for _, item := range v {
+				params.Add(prefix+name, fmt.Sprint(item))
+			}
+		default:
+			params.Set(prefix+name, fmt.Sprint(v))
+		}
+	}
+}
+
+// get fetches route from the endpoint and hands a successful body to decode. Errors reported by the
+// gateway are mapped onto ErrNotFound and ErrUnknownQuery like those of the other transports.
+func (t *RESTTransport) get(ctx context.Context, route string, decode func([]byte) error) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, t.address+route, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := t.http.Do(request)
+	if err != nil {
+		return fmt.Errorf("Could not complete REST query: %w", err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("Could not read REST response: %w", err)
+	}
+
+	if response.StatusCode == http.StatusOK {
+		err = decode(body)
+		if err != nil {
+			return fmt.Errorf("Could not unmarshal REST response: %w", err)
+		}
+		return nil
+	}
+
+	var gatewayErr struct {
+		Code    codes.Code `json:"code"`
+		Message string     `json:"message"`
+	}
+	_ = json.Unmarshal(body, &gatewayErr)
+	switch {
+	case gatewayErr.Code == codes.NotFound || (gatewayErr.Code == codes.OK && response.StatusCode == http.StatusNotFound):
+		return ErrNotFound
+	case gatewayErr.Code == codes.Unimplemented || response.StatusCode == http.StatusNotImplemented:
+		return ErrUnknownQuery
+	case gatewayErr.Message != "":
+		return fmt.Errorf("query failed: %s", gatewayErr.Message)
+	default:
+		return fmt.Errorf("query failed: %s", response.Status)
+	}
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRESTAccountOfUnknownType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cosmos/base/tendermint/v1beta1/syncing":
+			w.Write([]byte(`{"syncing":false}`))
+		case "/cosmos/auth/v1beta1/accounts/evmos1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twmaswj7":
+			w.Write([]byte(`{"account":{"@type":"/ethermint.types.v1.EthAccount","base_account":{"address":"evmos1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twmaswj7","account_number":"7","sequence":"3"},"code_hash":"0xc5d2"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	transport, err := NewRESTTransport(ctx, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, account, err := QueryAuthAccount(ctx, transport, "evmos1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twmaswj7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw == nil || raw.TypeUrl != "/ethermint.types.v1.EthAccount" {
+		t.Fatalf("got account %v, want an Any of type /ethermint.types.v1.EthAccount", raw)
+	}
+	if account != nil {
+		t.Errorf("got account %v, want none for an unknown type", account)
+	}
+}
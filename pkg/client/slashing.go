@@ -0,0 +1,20 @@
+package client
+
+import (
+	"context"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+)
+
+// QuerySigningInfo returns the liveness record of the validator with the bech32 valcons address consAddress
+func QuerySigningInfo(ctx context.Context, client Transport, consAddress string) (slashingtypes.ValidatorSigningInfo, error) {
+	resp := slashingtypes.QuerySigningInfoResponse{}
+	err := client.Query(ctx, "/cosmos.slashing.v1beta1.Query/SigningInfo", &slashingtypes.QuerySigningInfoRequest{ConsAddress: consAddress}, &resp)
+	return resp.ValSigningInfo, err
+}
+
+// QuerySlashingParams returns the signing window and downtime thresholds of the chain
+func QuerySlashingParams(ctx context.Context, client Transport) (slashingtypes.Params, error) {
+	resp := slashingtypes.QueryParamsResponse{}
+	err := client.Query(ctx, "/cosmos.slashing.v1beta1.Query/Params", &slashingtypes.QueryParamsRequest{}, &resp)
+	return resp.Params, err
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+	staketypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// The staking denom of a chain is fixed at genesis for all practical purposes, so it is cached per chain
+var (
+	bondDenomMux   sync.Mutex
+	bondDenomCache = make(map[string]string)
+)
+
+// QueryBondDenom returns the staking denom of the chain, e.g. uatom
+func QueryBondDenom(ctx context.Context, client Transport, chain string) (string, error) {
+	bondDenomMux.Lock()
+	denom, ok := bondDenomCache[chain]
+	bondDenomMux.Unlock()
+	if ok {
+		return denom, nil
+	}
+
+	resp := staketypes.QueryParamsResponse{}
+	err := client.Query(ctx, "/cosmos.staking.v1beta1.Query/Params", &staketypes.QueryParamsRequest{}, &resp)
+	if err != nil {
+		return "", err
+	}
+	bondDenomMux.Lock()
+	bondDenomCache[chain] = resp.Params.BondDenom
+	bondDenomMux.Unlock()
+	return resp.Params.BondDenom, nil
+}
+
+// QueryValidator returns the validator for a valoper address
+func QueryValidator(ctx context.Context, client Transport, valoper string) (staketypes.Validator, error) {
+	resp := staketypes.QueryValidatorResponse{}
+	err := client.Query(ctx, "/cosmos.staking.v1beta1.Query/Validator", &staketypes.QueryValidatorRequest{ValidatorAddr: valoper}, &resp)
+	return resp.Validator, err
+}
+
+// QueryBondedValidators returns the active validator set
+func QueryBondedValidators(ctx context.Context, client Transport) (validators []staketypes.Validator, err error) {
+	var nextKey []byte
+	for {
+		req := staketypes.QueryValidatorsRequest{
+			Status:     staketypes.Bonded.String(),
+			Pagination: &querytypes.PageRequest{Key: nextKey},
+		}
+		resp := staketypes.QueryValidatorsResponse{}
+		err = client.Query(ctx, "/cosmos.staking.v1beta1.Query/Validators", &req, &resp)
+		if err != nil {
+			return
+		}
+		validators = append(validators, resp.Validators...)
+		if resp.Pagination == nil || len(resp.Pagination.NextKey) == 0 {
+			return
+		}
+		nextKey = resp.Pagination.NextKey
+	}
+}
+
+// QueryDelegation returns the delegation of delegator to a single validator
+func QueryDelegation(ctx context.Context, client Transport, delegator, valoper string) (delegation staketypes.DelegationResponse, err error) {
+	resp := staketypes.QueryDelegationResponse{}
+	err = client.Query(ctx, "/cosmos.staking.v1beta1.Query/Delegation", &staketypes.QueryDelegationRequest{DelegatorAddr: delegator, ValidatorAddr: valoper}, &resp)
+	if resp.DelegationResponse != nil {
+		delegation = *resp.DelegationResponse
+	}
+	return
+}
+
+// QueryDelegations returns every delegation made by delegator
+func QueryDelegations(ctx context.Context, client Transport, delegator string) (delegations []staketypes.DelegationResponse, err error) {
+	var nextKey []byte
+	for {
+		req := staketypes.QueryDelegatorDelegationsRequest{
+			DelegatorAddr: delegator,
+			Pagination:    &querytypes.PageRequest{Key: nextKey},
+		}
+		resp := staketypes.QueryDelegatorDelegationsResponse{}
+		err = client.Query(ctx, "/cosmos.staking.v1beta1.Query/DelegatorDelegations", &req, &resp)
+		if err != nil {
+			return
+		}
+		delegations = append(delegations, resp.DelegationResponses...)
+		if resp.Pagination == nil || len(resp.Pagination.NextKey) == 0 {
+			return
+		}
+		nextKey = resp.Pagination.NextKey
+	}
+}
+
+// QueryUnbondingDelegations returns every unbonding delegation of delegator that has not yet matured
+func QueryUnbondingDelegations(ctx context.Context, client Transport, delegator string) (unbonding []staketypes.UnbondingDelegation, err error) {
+	var nextKey []byte
+	for {
+		req := staketypes.QueryDelegatorUnbondingDelegationsRequest{
+			DelegatorAddr: delegator,
+			Pagination:    &querytypes.PageRequest{Key: nextKey},
+		}
+		resp := staketypes.QueryDelegatorUnbondingDelegationsResponse{}
+		err = client.Query(ctx, "/cosmos.staking.v1beta1.Query/DelegatorUnbondingDelegations", &req, &resp)
+		if err != nil {
+			return
+		}
+		unbonding = append(unbonding, resp.UnbondingResponses...)
+		if resp.Pagination == nil || len(resp.Pagination.NextKey) == 0 {
+			return
+		}
+		nextKey = resp.Pagination.NextKey
+	}
+}
+
+// QueryRedelegations returns every pending redelegation of delegator
+func QueryRedelegations(ctx context.Context, client Transport, delegator string) (redelegations []staketypes.RedelegationResponse, err error) {
+	var nextKey []byte
+	for {
+		req := staketypes.QueryRedelegationsRequest{
+			DelegatorAddr: delegator,
+			Pagination:    &querytypes.PageRequest{Key: nextKey},
+		}
+		resp := staketypes.QueryRedelegationsResponse{}
+		err = client.Query(ctx, "/cosmos.staking.v1beta1.Query/Redelegations", &req, &resp)
+		if err != nil {
+			return
+		}
+		redelegations = append(redelegations, resp.RedelegationResponses...)
+		if resp.Pagination == nil || len(resp.Pagination.NextKey) == 0 {
+			return
+		}
+		nextKey = resp.Pagination.NextKey
+	}
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+)
+
+// Names of the transports, as reported in search results
+const (
+	TransportRPC  = "rpc"
+	TransportGRPC = "grpc"
+	TransportREST = "rest"
+)
+
+// ProtoMessage is satisfied by the gogoproto generated query request and response types
+type ProtoMessage interface {
+	Marshal() ([]byte, error)
+	Unmarshal([]byte) error
+}
+
+// Transport carries the gRPC queries of the Cosmos SDK modules to a chain. Every query function in this
+// package takes a Transport, so a chain can be searched over whichever kind of endpoint is reachable.
+type Transport interface {
+	// Query sends req to the query method at path, e.g. /cosmos.bank.v1beta1.Query/AllBalances, and
+	// unmarshals the reply into resp. resp is left untouched when the reply is empty. Objects the chain
+	// does not know about are reported as ErrNotFound and modules it does not run as ErrUnknownQuery.
+	Query(ctx context.Context, path string, req, resp ProtoMessage) error
+	// Check returns an error unless the endpoint answers and is in sync with the chain
+	Check(ctx context.Context) error
+	// Name is the kind of transport, e.g. TransportRPC
+	Name() string
+	// Endpoint is the address of the endpoint queries are sent to
+	Endpoint() string
+}
+
+// RPCTransport sends queries over ABCI to a Tendermint RPC endpoint
+type RPCTransport struct {
+	Client *rpchttp.HTTP
+}
+
+func (t RPCTransport) Name() string {
+	return TransportRPC
+}
+
+func (t RPCTransport) Endpoint() string {
+	return t.Client.Remote()
+}
+
+func (t RPCTransport) Check(ctx context.Context) error {
+	status, err := t.Client.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.SyncInfo.CatchingUp {
+		return errors.New("node is catching up")
+	}
+	return nil
+}
+
+// Query marshals req, sends it to the gRPC query path over ABCI and unmarshals the reply into resp
+func (t RPCTransport) Query(ctx context.Context, path string, req, resp ProtoMessage) error {
+	query, err := req.Marshal()
+	if err != nil {
+		return fmt.Errorf("Could not marshal request for %s: %w", path, err)
+	}
+	result, err := t.Client.ABCIQuery(ctx, path, query)
+	if err != nil {
+		return fmt.Errorf("Could not complete ABCIQuery: %w", err)
+	}
+	if result.Response.Code != 0 {
+		if strings.Contains(result.Response.Log, "not found") || strings.Contains(result.Response.Log, "code = NotFound") {
+			return fmt.Errorf("%s: %w", path, ErrNotFound)
+		}
+		if strings.Contains(result.Response.Log, "unknown query path") {
+			return fmt.Errorf("%s: %w", path, ErrUnknownQuery)
+		}
+		return fmt.Errorf("%s query failed: %s", path, result.Response.Log)
+	}
+	if len(result.Response.Value) == 0 {
+		return nil
+	}
+	err = resp.Unmarshal(result.Response.Value)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal response for %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package mockrpc
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"cosmossdk.io/math"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	staketypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	connectiontypes "github.com/cosmos/ibc-go/v7/modules/core/03-connection/types"
+	"github.com/johnsaigle/findaccount/pkg/client"
+	"github.com/johnsaigle/findaccount/types"
+)
+
+// DefaultFixture holds a cosmoshub and an osmosis chain sharing an account that operates a validator,
+// delegates, has rewards and controls an interchain account
+//
+//go:embed fixtures/chains.json
+var DefaultFixture []byte
+
+// Fixture describes the state of every chain served by a Network
+type Fixture struct {
+	Chains []Chain `json:"chains"`
+}
+
+// Chain is the state of one chain. Coin amounts are written the way the Cosmos SDK parses them, e.g.
+// "1000uatom,5ibc/27394FB0...".
+type Chain struct {
+	// Name is the directory of the chain in the synthetic chain-registry
+	Name         string   `json:"name"`
+	ChainId      string   `json:"chain_id"`
+	Bech32Prefix string   `json:"bech32_prefix"`
+	Slip44       uint32   `json:"slip44"`
+	KeyAlgos     []string `json:"key_algos"`
+	BondDenom    string   `json:"bond_denom"`
+	Height       int64    `json:"height"`
+	// CatchingUp makes the node report it is still syncing, so the chain has no healthy endpoint
+	CatchingUp bool `json:"catching_up"`
+	// Assets are written to the assetlist.json of the chain
+	Assets      []types.Asset `json:"assets"`
+	Accounts    []Account     `json:"accounts"`
+	Validators  []Validator   `json:"validators"`
+	Delegations []Delegation  `json:"delegations"`
+	Unbonding   []Unbonding   `json:"unbonding"`
+	Rewards     []Reward      `json:"rewards"`
+	// DenomTraces maps ibc/ denoms onto their path and base denom, e.g. "transfer/channel-0/uosmo"
+	DenomTraces        map[string]string  `json:"denom_traces"`
+	InterchainAccounts InterchainAccounts `json:"interchain_accounts"`
+}
+
+type Account struct {
+	Address       string `json:"address"`
+	Coins         string `json:"coins"`
+	AccountNumber uint64 `json:"account_number"`
+	Sequence      uint64 `json:"sequence"`
+}
+
+type Validator struct {
+	OperatorAddress string `json:"operator_address"`
+	Moniker         string `json:"moniker"`
+	// Status is bonded, unbonding or unbonded
+	Status string `json:"status"`
+	Jailed bool   `json:"jailed"`
+	Tokens int64  `json:"tokens"`
+	// ConsensusPubkey is a base64 ed25519 key. One is derived from the operator address when it is empty.
+	ConsensusPubkey string `json:"consensus_pubkey"`
+	CommissionRate  string `json:"commission_rate"`
+	// Commission is the accumulated commission of the validator, as decimal coins
+	Commission   string `json:"commission"`
+	MissedBlocks int64  `json:"missed_blocks"`
+}
+
+type Delegation struct {
+	Delegator string `json:"delegator"`
+	Validator string `json:"validator"`
+	Amount    int64  `json:"amount"`
+}
+
+type Unbonding struct {
+	Delegator      string    `json:"delegator"`
+	Validator      string    `json:"validator"`
+	Amount         int64     `json:"amount"`
+	CompletionTime time.Time `json:"completion_time"`
+}
+
+type Reward struct {
+	Delegator string `json:"delegator"`
+	Validator string `json:"validator"`
+	// Amount is given as decimal coins, e.g. "12.5uatom"
+	Amount string `json:"amount"`
+}
+
+type InterchainAccounts struct {
+	ControllerEnabled bool `json:"controller_enabled"`
+	// Connections are the ids of the open IBC connections of the chain
+	Connections []string                 `json:"connections"`
+	Accounts    []InterchainAccountEntry `json:"accounts"`
+}
+
+type InterchainAccountEntry struct {
+	Owner        string `json:"owner"`
+	ConnectionId string `json:"connection_id"`
+	Address      string `json:"address"`
+}
+
+// ParseFixture decodes a Fixture from its JSON form
+func ParseFixture(r io.Reader) (*Fixture, error) {
+	fixture := &Fixture{}
+	err := json.NewDecoder(r).Decode(fixture)
+	if err != nil {
+		return nil, fmt.Errorf("Could not decode fixture: %w", err)
+	}
+	return fixture, nil
+}
+
+// ReadFixture reads the Fixture at path
+func ReadFixture(path string) (*Fixture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not open fixture: %w", err)
+	}
+	defer f.Close()
+	return ParseFixture(f)
+}
+
+// Querier builds the in-memory state of the chain, which the Server of the chain answers queries from.
+// It can also be used directly as a client.ChainQuerier.
+func (c Chain) Querier() (*client.MemQuerier, error) {
+	q := &client.MemQuerier{
+		Denom:                    c.BondDenom,
+		Coins:                    make(map[string]sdk.Coins),
+		Traces:                   make(map[string]transfertypes.DenomTrace),
+		Accounts:                 make(map[string]authtypes.AccountI),
+		Validators:               make(map[string]staketypes.Validator),
+		DelegationsByDelegator:   make(map[string][]staketypes.DelegationResponse),
+		Unbondings:               make(map[string][]staketypes.UnbondingDelegation),
+		RedelegationsByDelegator: make(map[string][]staketypes.RedelegationResponse),
+		Rewards:                  make(map[string][]distrtypes.DelegationDelegatorReward),
+		Commissions:              make(map[string]sdk.DecCoins),
+		SigningInfos:             make(map[string]slashingtypes.ValidatorSigningInfo),
+		Slashing:                 slashingtypes.DefaultParams(),
+		ICAEnabled:               c.InterchainAccounts.ControllerEnabled,
+		ICAs:                     make(map[string]string),
+	}
+
+	for _, a := range c.Accounts {
+		addr, err := sdk.GetFromBech32(a.Address, c.Bech32Prefix)
+		if err != nil {
+			return nil, fmt.Errorf("Could not decode account %s: %w", a.Address, err)
+		}
+		coins, err := sdk.ParseCoinsNormalized(a.Coins)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse coins of %s: %w", a.Address, err)
+		}
+		q.Coins[a.Address] = coins
+		q.Accounts[a.Address] = authtypes.NewBaseAccount(addr, nil, a.AccountNumber, a.Sequence)
+	}
+
+	for denom, path := range c.DenomTraces {
+		q.Traces[denom] = transfertypes.ParseDenomTrace(path)
+	}
+
+	for _, v := range c.Validators {
+		val, consAddress, err := c.validator(v)
+		if err != nil {
+			return nil, err
+		}
+		q.Validators[v.OperatorAddress] = val
+		q.SigningInfos[consAddress] = slashingtypes.ValidatorSigningInfo{
+			Address:             consAddress,
+			MissedBlocksCounter: v.MissedBlocks,
+		}
+		if v.Commission != "" {
+			commission, err := sdk.ParseDecCoins(v.Commission)
+			if err != nil {
+				return nil, fmt.Errorf("Could not parse commission of %s: %w", v.OperatorAddress, err)
+			}
+			q.Commissions[v.OperatorAddress] = commission
+		}
+	}
+
+	for _, d := range c.Delegations {
+		q.DelegationsByDelegator[d.Delegator] = append(q.DelegationsByDelegator[d.Delegator], staketypes.DelegationResponse{
+			Delegation: staketypes.Delegation{
+				DelegatorAddress: d.Delegator,
+				ValidatorAddress: d.Validator,
+				Shares:           math.LegacyNewDec(d.Amount),
+			},
+			Balance: sdk.NewInt64Coin(c.BondDenom, d.Amount),
+		})
+	}
+	for _, u := range c.Unbonding {
+		q.Unbondings[u.Delegator] = append(q.Unbondings[u.Delegator], staketypes.UnbondingDelegation{
+			DelegatorAddress: u.Delegator,
+			ValidatorAddress: u.Validator,
+			Entries: []staketypes.UnbondingDelegationEntry{{
+				CompletionTime: u.CompletionTime,
+				InitialBalance: math.NewInt(u.Amount),
+				Balance:        math.NewInt(u.Amount),
+			}},
+		})
+	}
+	for _, r := range c.Rewards {
+		reward, err := sdk.ParseDecCoins(r.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse rewards of %s: %w", r.Delegator, err)
+		}
+		q.Rewards[r.Delegator] = append(q.Rewards[r.Delegator], distrtypes.DelegationDelegatorReward{
+			ValidatorAddress: r.Validator,
+			Reward:           reward,
+		})
+	}
+
+	for i, id := range c.InterchainAccounts.Connections {
+		q.Connections = append(q.Connections, connectiontypes.IdentifiedConnection{
+			Id:       id,
+			ClientId: fmt.Sprintf("07-tendermint-%d", i),
+			State:    connectiontypes.OPEN,
+		})
+	}
+	for _, ica := range c.InterchainAccounts.Accounts {
+		q.ICAs[ica.Owner+"/"+ica.ConnectionId] = ica.Address
+	}
+	return q, nil
+}
+
+// validator builds the staking module view of v and returns it with its consensus address
+func (c Chain) validator(v Validator) (val staketypes.Validator, consAddress string, err error) {
+	valAddr, err := sdk.GetFromBech32(v.OperatorAddress, c.Bech32Prefix+"valoper")
+	if err != nil {
+		err = fmt.Errorf("Could not decode validator %s: %w", v.OperatorAddress, err)
+		return
+	}
+	key := sha256.Sum256([]byte(v.OperatorAddress))
+	pk := &ed25519.PubKey{Key: key[:]}
+	if v.ConsensusPubkey != "" {
+		pk.Key, err = base64.StdEncoding.DecodeString(v.ConsensusPubkey)
+		if err != nil || len(pk.Key) != ed25519.PubKeySize {
+			err = fmt.Errorf("Could not decode consensus pubkey of %s", v.OperatorAddress)
+			return
+		}
+	}
+	val, err = staketypes.NewValidator(sdk.ValAddress(valAddr), pk, staketypes.Description{Moniker: v.Moniker})
+	if err != nil {
+		err = fmt.Errorf("Could not build validator %s: %w", v.OperatorAddress, err)
+		return
+	}
+
+	switch v.Status {
+	case "bonded", "":
+		val.Status = staketypes.Bonded
+	case "unbonding":
+		val.Status = staketypes.Unbonding
+	case "unbonded":
+		val.Status = staketypes.Unbonded
+	default:
+		err = fmt.Errorf("validator %s has unknown status %q", v.OperatorAddress, v.Status)
+		return
+	}
+	val.Jailed = v.Jailed
+	val.Tokens = math.NewInt(v.Tokens)
+	val.DelegatorShares = math.LegacyNewDec(v.Tokens)
+	if v.CommissionRate != "" {
+		rate, e := math.LegacyNewDecFromStr(v.CommissionRate)
+		if e != nil {
+			err = fmt.Errorf("Could not parse commission rate of %s: %w", v.OperatorAddress, e)
+			return
+		}
+		val.Commission = staketypes.NewCommission(rate, math.LegacyOneDec(), math.LegacyOneDec())
+	}
+
+	consAddress, err = bech32.ConvertAndEncode(c.Bech32Prefix+"valcons", pk.Address())
+	return
+}
@@ -0,0 +1,130 @@
+// Package mockrpc serves chains described by a fixture over Tendermint RPC, together with a synthetic
+// chain-registry pointing at them, so searches can be run end to end without network access.
+//
+// A test of the search engine typically starts a Network, loads its registry and searches:
+//
+//	network, err := mockrpc.Start(fixture)
+//	defer network.Close()
+//	err = chaininfo.Load(network.Registry())
+//	results, err := findaccount.SearchAccounts(ctx, address, "", "", "")
+//
+// The CLI and findaccount-server take the same registry through their registry flags after it has been
+// written out with WriteRegistry.
+package mockrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing/fstest"
+
+	"github.com/johnsaigle/findaccount/types"
+)
+
+// Network is a set of running chains, one Server each
+type Network struct {
+	Servers map[string]*Server
+	chains  map[string]Chain
+}
+
+// Start serves every chain of fixture. The servers run until Close is called.
+func Start(fixture *Fixture) (*Network, error) {
+	network := &Network{
+		Servers: make(map[string]*Server),
+		chains:  make(map[string]Chain),
+	}
+	for _, chain := range fixture.Chains {
+		if _, ok := network.chains[chain.Name]; ok {
+			network.Close()
+			return nil, fmt.Errorf("chain %s is defined twice", chain.Name)
+		}
+		q, err := chain.Querier()
+		if err != nil {
+			network.Close()
+			return nil, fmt.Errorf("Could not build state of %s: %w", chain.Name, err)
+		}
+		server := NewServer(chain.ChainId, chain.Height, q)
+		server.SetCatchingUp(chain.CatchingUp)
+		network.Servers[chain.Name] = server
+		network.chains[chain.Name] = chain
+	}
+	return network, nil
+}
+
+// StartDefault serves DefaultFixture
+func StartDefault() (*Network, error) {
+	fixture, err := ParseFixture(bytes.NewReader(DefaultFixture))
+	if err != nil {
+		return nil, err
+	}
+	return Start(fixture)
+}
+
+func (n *Network) Close() {
+	for _, server := range n.Servers {
+		server.Close()
+	}
+}
+
+// registryChain is the part of chain.json the chaininfo package reads, plus the identifying fields of the
+// real file
+type registryChain struct {
+	ChainName    string   `json:"chain_name"`
+	ChainId      string   `json:"chain_id"`
+	Bech32Prefix string   `json:"bech32_prefix"`
+	Slip44       uint32   `json:"slip44"`
+	KeyAlgos     []string `json:"key_algos,omitempty"`
+	Apis         struct {
+		Rpc []types.Rpc `json:"rpc"`
+	} `json:"apis"`
+}
+
+// Registry returns a chain-registry listing every chain of the network with its Server as the only RPC
+// endpoint, in the layout chaininfo.Load expects
+func (n *Network) Registry() fstest.MapFS {
+	registry := make(fstest.MapFS)
+	names := make([]string, 0, len(n.chains))
+	for name := range n.chains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		chain := n.chains[name]
+		info := registryChain{
+			ChainName:    name,
+			ChainId:      chain.ChainId,
+			Bech32Prefix: chain.Bech32Prefix,
+			Slip44:       chain.Slip44,
+			KeyAlgos:     chain.KeyAlgos,
+		}
+		info.Apis.Rpc = []types.Rpc{{Address: n.Servers[name].URL}}
+		b, _ := json.MarshalIndent(info, "", "  ")
+		registry[name+"/chain.json"] = &fstest.MapFile{Data: b, Mode: 0o644}
+
+		if len(chain.Assets) == 0 {
+			continue
+		}
+		b, _ = json.MarshalIndent(types.AssetList{ChainName: name, Assets: chain.Assets}, "", "  ")
+		registry[name+"/assetlist.json"] = &fstest.MapFile{Data: b, Mode: 0o644}
+	}
+	return registry
+}
+
+// WriteRegistry writes Registry to dir, creating it if needed
+func (n *Network) WriteRegistry(dir string) error {
+	for path, file := range n.Registry() {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			return fmt.Errorf("Could not create registry directory: %w", err)
+		}
+		err = os.WriteFile(path, file.Data, file.Mode)
+		if err != nil {
+			return fmt.Errorf("Could not write registry: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package mockrpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/johnsaigle/findaccount/pkg/client"
+)
+
+func TestServer(t *testing.T) {
+	network, err := StartDefault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer network.Close()
+
+	ctx := context.Background()
+	server := network.Servers["cosmoshub"]
+	rpc, err := client.NewClient(ctx, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := client.NewQuerier("cosmoshub", client.RPCTransport{Client: rpc})
+
+	coins, err := q.Balances(ctx, "cosmos1aeh8gqu9wr4u8ev6edlgfq03rcy6v5twlpvf6m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coins.String() != "2500000ibc/14F9BC3E44B8A9C1BE1FB08980FAB87034C9905EF17CF2F5008FC085218811CC,37256755969uatom" {
+		t.Errorf("got balances %s", coins)
+	}
+	val, err := q.Validator(ctx, "cosmosvaloper1aeh8gqu9wr4u8ev6edlgfq03rcy6v5tw64cukg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val.GetMoniker() != "Example" || val.Tokens.Int64() != 5000000000 {
+		t.Errorf("got validator %s with %s tokens", val.GetMoniker(), val.Tokens)
+	}
+	valoper, err := client.ValoperAddress("cosmos1kzvsfy5p75tm7p7sdnsvt7lmugmputuqzgytgf", "cosmos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = q.Validator(ctx, valoper)
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("got %v for an unknown validator, want ErrNotFound", err)
+	}
+	if server.Queries() == 0 {
+		t.Error("queries were not counted")
+	}
+
+	server.SetCatchingUp(true)
+	if err = (client.RPCTransport{Client: rpc}).Check(ctx); err == nil {
+		t.Error("a node that is catching up passed the health check")
+	}
+}
+
+func TestStartRejectsDuplicateChains(t *testing.T) {
+	_, err := Start(&Fixture{Chains: []Chain{{Name: "cosmoshub"}, {Name: "cosmoshub"}}})
+	if err == nil {
+		t.Error("started a network with a chain defined twice")
+	}
+}
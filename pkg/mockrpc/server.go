@@ -0,0 +1,295 @@
+package mockrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	staketypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	icacontrollertypes "github.com/cosmos/ibc-go/v7/modules/apps/27-interchain-accounts/controller/types"
+	transfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	connectiontypes "github.com/cosmos/ibc-go/v7/modules/core/03-connection/types"
+	"github.com/johnsaigle/findaccount/pkg/client"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/bytes"
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	"github.com/tendermint/tendermint/p2p"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+)
+
+// Server is a Tendermint RPC endpoint for a single chain. It speaks enough JSON-RPC for the clients in
+// this repository: status, and abci_query for the gRPC query paths the search sends.
+type Server struct {
+	*httptest.Server
+	chainId string
+	querier client.ChainQuerier
+
+	mux        sync.Mutex
+	height     int64
+	catchingUp bool
+	queries    int
+}
+
+// NewServer starts serving the chain with chainId from querier
+func NewServer(chainId string, height int64, querier client.ChainQuerier) *Server {
+	s := &Server{chainId: chainId, querier: querier, height: height}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// SetHeight changes the height the node reports, e.g. to make it lag behind other nodes of the chain
+func (s *Server) SetHeight(height int64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.height = height
+}
+
+// SetCatchingUp changes whether the node reports it is still syncing
+func (s *Server) SetCatchingUp(catchingUp bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.catchingUp = catchingUp
+}
+
+// Queries is the number of abci_query requests the node has answered
+func (s *Server) Queries() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.queries
+}
+
+func (s *Server) serveHTTP(writer http.ResponseWriter, request *http.Request) {
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	req := rpctypes.RPCRequest{}
+	var resp rpctypes.RPCResponse
+	err = json.Unmarshal(body, &req)
+	switch {
+	case err != nil:
+		resp = rpctypes.RPCParseError(err)
+	case req.Method == "status":
+		resp = rpctypes.NewRPCSuccessResponse(req.ID, s.status())
+	case req.Method == "abci_query":
+		params := struct {
+			Path string         `json:"path"`
+			Data bytes.HexBytes `json:"data"`
+		}{}
+		err = tmjson.Unmarshal(req.Params, &params)
+		if err != nil {
+			resp = rpctypes.RPCInvalidParamsError(req.ID, err)
+			break
+		}
+		resp = rpctypes.NewRPCSuccessResponse(req.ID, s.abciQuery(request.Context(), params.Path, params.Data))
+	default:
+		resp = rpctypes.RPCMethodNotFoundError(req.ID)
+	}
+
+	out, err := json.Marshal(resp)
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	_, _ = writer.Write(out)
+}
+
+func (s *Server) status() *ctypes.ResultStatus {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return &ctypes.ResultStatus{
+		NodeInfo: p2p.DefaultNodeInfo{Network: s.chainId, Moniker: "mockrpc"},
+		SyncInfo: ctypes.SyncInfo{
+			LatestBlockHeight: s.height,
+			LatestBlockTime:   time.Now().UTC(),
+			CatchingUp:        s.catchingUp,
+		},
+	}
+}
+
+// abciQuery answers a gRPC query the way the ABCI application of a node does, reporting failures in the
+// code and log of the response rather than as JSON-RPC errors
+func (s *Server) abciQuery(ctx context.Context, path string, data []byte) *ctypes.ResultABCIQuery {
+	s.mux.Lock()
+	s.queries++
+	height := s.height
+	s.mux.Unlock()
+
+	result := &ctypes.ResultABCIQuery{Response: abci.ResponseQuery{Height: height}}
+	handler, ok := handlers[path]
+	if !ok {
+		result.Response.Code = sdkerrors.ErrUnknownRequest.ABCICode()
+		result.Response.Log = fmt.Sprintf("unknown query path: %s", path)
+		return result
+	}
+	resp, err := handler(ctx, s.querier, data)
+	if err == nil {
+		result.Response.Value, err = resp.Marshal()
+	}
+	switch {
+	case errors.Is(err, client.ErrNotFound):
+		result.Response.Code = sdkerrors.ErrKeyNotFound.ABCICode()
+		result.Response.Log = fmt.Sprintf("rpc error: code = NotFound desc = %s", err)
+	case err != nil:
+		result.Response.Code = sdkerrors.ErrInvalidRequest.ABCICode()
+		result.Response.Log = err.Error()
+	}
+	return result
+}
+
+// handler decodes the request of a query, answers it from q and returns the response to encode
+type handler func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error)
+
+// handlers covers every gRPC query path sent by the client package. Paginated queries are answered in a
+// single page.
+var handlers = map[string]handler{
+	"/cosmos.auth.v1beta1.Query/Account": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		req := authtypes.QueryAccountRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return nil, err
+		}
+		raw, _, err := q.AuthAccount(ctx, req.Address)
+		return &authtypes.QueryAccountResponse{Account: raw}, err
+	},
+	"/cosmos.bank.v1beta1.Query/AllBalances": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		req := banktypes.QueryAllBalancesRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return nil, err
+		}
+		balances, err := q.Balances(ctx, req.Address)
+		return &banktypes.QueryAllBalancesResponse{Balances: balances}, err
+	},
+	"/cosmos.distribution.v1beta1.Query/DelegationTotalRewards": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		req := distrtypes.QueryDelegationTotalRewardsRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return nil, err
+		}
+		rewards, total, err := q.DelegationRewards(ctx, req.DelegatorAddress)
+		return &distrtypes.QueryDelegationTotalRewardsResponse{Rewards: rewards, Total: total}, err
+	},
+	"/cosmos.distribution.v1beta1.Query/ValidatorCommission": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		req := distrtypes.QueryValidatorCommissionRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return nil, err
+		}
+		commission, err := q.ValidatorCommission(ctx, req.ValidatorAddress)
+		return &distrtypes.QueryValidatorCommissionResponse{Commission: distrtypes.ValidatorAccumulatedCommission{Commission: commission}}, err
+	},
+	"/cosmos.slashing.v1beta1.Query/Params": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		params, err := q.SlashingParams(ctx)
+		return &slashingtypes.QueryParamsResponse{Params: params}, err
+	},
+	"/cosmos.slashing.v1beta1.Query/SigningInfo": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		req := slashingtypes.QuerySigningInfoRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return nil, err
+		}
+		info, err := q.SigningInfo(ctx, req.ConsAddress)
+		return &slashingtypes.QuerySigningInfoResponse{ValSigningInfo: info}, err
+	},
+	"/cosmos.staking.v1beta1.Query/Delegation": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		req := staketypes.QueryDelegationRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return nil, err
+		}
+		delegation, err := q.Delegation(ctx, req.DelegatorAddr, req.ValidatorAddr)
+		return &staketypes.QueryDelegationResponse{DelegationResponse: &delegation}, err
+	},
+	"/cosmos.staking.v1beta1.Query/DelegatorDelegations": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		req := staketypes.QueryDelegatorDelegationsRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return nil, err
+		}
+		delegations, err := q.Delegations(ctx, req.DelegatorAddr)
+		return &staketypes.QueryDelegatorDelegationsResponse{DelegationResponses: delegations}, err
+	},
+	"/cosmos.staking.v1beta1.Query/DelegatorUnbondingDelegations": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		req := staketypes.QueryDelegatorUnbondingDelegationsRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return nil, err
+		}
+		unbonding, err := q.UnbondingDelegations(ctx, req.DelegatorAddr)
+		return &staketypes.QueryDelegatorUnbondingDelegationsResponse{UnbondingResponses: unbonding}, err
+	},
+	"/cosmos.staking.v1beta1.Query/Params": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		denom, err := q.BondDenom(ctx)
+		params := staketypes.DefaultParams()
+		params.BondDenom = denom
+		return &staketypes.QueryParamsResponse{Params: params}, err
+	},
+	"/cosmos.staking.v1beta1.Query/Redelegations": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		req := staketypes.QueryRedelegationsRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return nil, err
+		}
+		redelegations, err := q.Redelegations(ctx, req.DelegatorAddr)
+		return &staketypes.QueryRedelegationsResponse{RedelegationResponses: redelegations}, err
+	},
+	"/cosmos.staking.v1beta1.Query/Validator": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		req := staketypes.QueryValidatorRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return nil, err
+		}
+		val, err := q.Validator(ctx, req.ValidatorAddr)
+		return &staketypes.QueryValidatorResponse{Validator: val}, err
+	},
+	"/cosmos.staking.v1beta1.Query/Validators": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		req := staketypes.QueryValidatorsRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return nil, err
+		}
+		if req.Status != staketypes.Bonded.String() {
+			return nil, fmt.Errorf("only %s validators are served", staketypes.Bonded)
+		}
+		validators, err := q.BondedValidators(ctx)
+		return &staketypes.QueryValidatorsResponse{Validators: validators}, err
+	},
+	"/ibc.applications.interchain_accounts.controller.v1.Query/InterchainAccount": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		req := icacontrollertypes.QueryInterchainAccountRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return nil, err
+		}
+		addr, err := q.InterchainAccount(ctx, req.Owner, req.ConnectionId)
+		return &icacontrollertypes.QueryInterchainAccountResponse{Address: addr}, err
+	},
+	"/ibc.applications.interchain_accounts.controller.v1.Query/Params": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		enabled, err := q.ControllerEnabled(ctx)
+		return &icacontrollertypes.QueryParamsResponse{Params: &icacontrollertypes.Params{ControllerEnabled: enabled}}, err
+	},
+	"/ibc.applications.transfer.v1.Query/DenomTrace": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		req := transfertypes.QueryDenomTraceRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return nil, err
+		}
+		denom := "ibc/" + strings.ToUpper(strings.TrimPrefix(req.Hash, "ibc/"))
+		trace, ok := q.DenomTraces(ctx, sdk.Coins{{Denom: denom, Amount: sdk.OneInt()}})[denom]
+		if !ok {
+			return nil, fmt.Errorf("denom trace %s: %w", denom, client.ErrNotFound)
+		}
+		return &transfertypes.QueryDenomTraceResponse{DenomTrace: &trace}, nil
+	},
+	"/ibc.core.connection.v1.Query/Connections": func(ctx context.Context, q client.ChainQuerier, data []byte) (client.ProtoMessage, error) {
+		connections, err := q.OpenConnections(ctx)
+		resp := &connectiontypes.QueryConnectionsResponse{}
+		for i := range connections {
+			resp.Connections = append(resp.Connections, &connections[i])
+		}
+		return resp, err
+	},
+}
@@ -1,12 +1,13 @@
 async function doSearch() {
     hideTable()
     pleaseWait()
-    const addr = document.getElementById('inputAddress').value
+    const addr = document.getElementById('inputAddress').value.trim()
+    const param = isPubKey(addr) ? "pubkey" : "addr"
     document.getElementById('tableDiv').hidden = false
 
     let data
     try {
-        const response = await fetch("/q?addr=" + addr, {
+        const response = await fetch("/q?" + param + "=" + encodeURIComponent(addr), {
             method: 'GET',
             mode: 'cors',
             cache: 'no-cache',
@@ -29,6 +30,13 @@ async function doSearch() {
     }
 }
 
+// isPubKey recognises the public key formats accepted by the server: Any JSON, hex and base64
+function isPubKey(s) {
+    return s.startsWith("{") ||
+        /^(0x)?([0-9a-fA-F]{66}|[0-9a-fA-F]{130})$/.test(s) ||
+        /^([A-Za-z0-9+/]{44}|[A-Za-z0-9+/]{87}=)$/.test(s)
+}
+
 function hideTable() {
     document.getElementById('tableDiv').hidden = true
     document.getElementById('tableDiv').innerHTML = ""
@@ -41,19 +49,33 @@ function showTable(data) {
       <tr>
         <th scope="col">Chain</th>
         <th scope="col">Address</th>
-        <th scope="col">Validator moniker</th>
+        <th scope="col">Validator</th>
+        <th scope="col">Account</th>
         <th scope="col">Coins</th>
+        <th scope="col">Staked</th>
+        <th scope="col">Rewards</th>
       </tr>
       </thead>
       <tbody>`
     data.forEach(row => {
-        if (row.hasBalance === true) {
+        const delegations = row.staking.delegations || []
+        const unbonding = row.staking.unbonding || []
+        const redelegations = row.staking.redelegations || []
+        if (row.exists === true) {
             rows += `
               <tr>
-              <td><a href="${row.link}/account/${row.address}" target="_new">${cap(row.chain)}</a></td>
-              <td>${row.address}</td>
-              <td>${row.is_validator}</td>
-              <td>${row.coins}</td>
+              <td><a href="${row.link}/account/${row.address}" target="_new">${cap(row.chain)}</a><br><small>${row.transport}</small></td>
+              <td>${row.address}<br><small>${row.derived_via === "ica" ? "interchain account via " + row.controller : row.hex_address + " (" + row.derivation + ")"}</small></td>
+              <td>${formatValidator(row.validator)}</td>
+              <td>${formatAccount(row.account)}</td>
+              <td>${row.coins.map(formatCoin).join("<br>")}</td>
+              <td>${delegations.map(d => d.moniker + ": " + formatCoin(d.balance))
+                .concat(unbonding.map(u => u.moniker + ": " + formatCoin(u.balance) + " (unbonding)"))
+                .concat(redelegations.map(r => r.source_moniker + " &rarr; " + r.destination_moniker + ": " + formatCoin(r.balance)))
+                .join("<br>")}</td>
+              <td>${(row.rewards.total || []).map(formatCoin)
+                .concat((row.rewards.commission || []).map(c => formatCoin(c) + " (commission)"))
+                .join("<br>")}</td>
               </tr>`
         }
     })
@@ -76,6 +98,50 @@ function pleaseWait() {
     document.getElementById('status').innerText = "Searching dozens of IBC enabled chains ... this can take a while, please be patient."
 }
 
+function formatValidator(v) {
+    if (v === null) {
+        return ""
+    }
+    let details = [v.status]
+    if (v.jailed) {
+        details.push("jailed")
+    }
+    if (v.rank > 0) {
+        details.push("#" + v.rank)
+    }
+    if (v.signing) {
+        if (v.signing.tombstoned) {
+            details.push("tombstoned")
+        }
+        details.push(`missed ${v.signing.missed_blocks}/${v.signing.signed_blocks_window}`)
+    }
+    return `${v.moniker} (${details.join(", ")})<br>${formatCoin(v.tokens)}, ${(Number(v.commission.rate) * 100).toFixed(2)}% commission`
+}
+
+function formatAccount(a) {
+    if (a === null) {
+        return ""
+    }
+    if (a.type === a.type_url) {
+        return a.type
+    }
+    let s = `${a.type.replace("_", " ")}, seq ${a.sequence}`
+    if (a.vesting) {
+        s += `<br>${a.vesting.locked.map(formatCoin).join(", ")} locked until ${a.vesting.end_time.substring(0, 10)}`
+    }
+    return s
+}
+
+function formatCoin(c) {
+    return groupThousands(c.display_amount) + " " + c.display_denom
+}
+
+function groupThousands(amount) {
+    const [whole, frac] = amount.split(".")
+    const grouped = whole.replace(/\B(?=(\d{3})+(?!\d))/g, ",")
+    return frac === undefined ? grouped : grouped + "." + frac
+}
+
 function cap(string) {
     return string.charAt(0).toUpperCase() + string.slice(1);
 }
\ No newline at end of file
@@ -0,0 +1,8 @@
+package static
+
+import "embed"
+
+// Fs holds the web frontend served by findaccount-server
+//
+//go:embed *.html *.js *.js.map *.css *.png *.svg
+var Fs embed.FS
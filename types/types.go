@@ -1,18 +1,123 @@
 package types
 
+import "strings"
+
 type ChainInfo struct {
 	Apis struct {
-		Rpc []Rpc `json:"rpc"`
+		Rpc  []Rpc  `json:"rpc"`
+		Grpc []Grpc `json:"grpc"`
+		Rest []Rest `json:"rest"`
 	} `json:"apis"`
 	Bech32Prefix string `json:"bech32_prefix"`
 	Explorers []Explorer `json:"explorers"`
+	// Slip44 is the BIP-44 coin type, 118 for most Cosmos chains and 60 for Ethermint chains
+	Slip44   uint32   `json:"slip44"`
+	KeyAlgos []string `json:"key_algos"`
+}
+
+const (
+	KeyAlgoSecp256k1    = "secp256k1"
+	KeyAlgoEthSecp256k1 = "ethsecp256k1"
+)
+
+// KeyAlgo returns the algorithm the chain uses to turn a public key into an address. Chains that do not
+// list their key algorithms are assumed to be ethsecp256k1 when they use coin type 60.
+func (c ChainInfo) KeyAlgo() string {
+	for _, algo := range c.KeyAlgos {
+		if algo == KeyAlgoEthSecp256k1 {
+			return KeyAlgoEthSecp256k1
+		}
+	}
+	if len(c.KeyAlgos) == 0 && c.Slip44 == 60 {
+		return KeyAlgoEthSecp256k1
+	}
+	return KeyAlgoSecp256k1
 }
 
 type Rpc struct {
 	Address string `json:"address"`
 }
 
+// Grpc is a gRPC endpoint, usually given as host:port without a scheme
+type Grpc struct {
+	Address string `json:"address"`
+}
+
+// Rest is an LCD endpoint serving the gRPC queries as JSON
+type Rest struct {
+	Address string `json:"address"`
+}
+
 type Explorer struct {
 	Url string `json:"url"`
 }
 
+// AssetList mirrors the assetlist.json file that accompanies chain.json in the chain-registry
+type AssetList struct {
+	ChainName string  `json:"chain_name"`
+	Assets    []Asset `json:"assets"`
+}
+
+type Asset struct {
+	Description string      `json:"description"`
+	DenomUnits  []DenomUnit `json:"denom_units"`
+	TypeAsset   string      `json:"type_asset"`
+	Base        string      `json:"base"`
+	Name        string      `json:"name"`
+	Display     string      `json:"display"`
+	Symbol      string      `json:"symbol"`
+	CoingeckoId string      `json:"coingecko_id"`
+	LogoURIs    LogoURIs    `json:"logo_URIs"`
+	Traces      []Trace     `json:"traces"`
+}
+
+// DisplayExponent returns the exponent of the display denom unit, or 0 if the display unit is not listed
+func (a Asset) DisplayExponent() uint32 {
+	for _, unit := range a.DenomUnits {
+		if unit.Denom == a.Display {
+			return unit.Exponent
+		}
+	}
+	return 0
+}
+
+// DisplaySymbol returns the ticker for the asset, falling back to the upper-cased display denom
+func (a Asset) DisplaySymbol() string {
+	if a.Symbol != "" {
+		return a.Symbol
+	}
+	if a.Display != "" {
+		return strings.ToUpper(a.Display)
+	}
+	return a.Base
+}
+
+type DenomUnit struct {
+	Denom    string   `json:"denom"`
+	Exponent uint32   `json:"exponent"`
+	Aliases  []string `json:"aliases"`
+}
+
+type LogoURIs struct {
+	Png string `json:"png"`
+	Svg string `json:"svg"`
+}
+
+// Trace describes where an asset came from, e.g. an IBC transfer from its origin chain
+type Trace struct {
+	Type         string            `json:"type"`
+	Counterparty TraceCounterparty `json:"counterparty"`
+	Chain        TraceChain        `json:"chain"`
+	Provider     string            `json:"provider"`
+}
+
+type TraceCounterparty struct {
+	ChainName string `json:"chain_name"`
+	BaseDenom string `json:"base_denom"`
+	ChannelId string `json:"channel_id"`
+}
+
+type TraceChain struct {
+	ChannelId string `json:"channel_id"`
+	Path      string `json:"path"`
+}